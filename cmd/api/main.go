@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	_ "time/tzdata"
 
 	"github.com/mabduqayum/ewallet/internal/config"
 	"github.com/mabduqayum/ewallet/internal/database"
@@ -23,7 +24,8 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	db, err := database.New(ctx, &cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -34,8 +36,13 @@ func main() {
 		log.Fatalf("Failed to run database migrations: %v", err)
 	}
 
-	s := server.New(&cfg.Server, db)
+	s, err := server.New(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
 	s.RegisterFiberRoutes()
+	s.StartBackgroundJobs(ctx)
+	s.WatchConfig()
 
 	log.Printf("Starting server on %s", cfg.Server.Address())
 	if err := s.Listen(); err != nil {
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/database"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
+
+	"github.com/google/uuid"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const usage = `usage:
+  client-credentials issue -client <id> [-expires-in <duration>] [-revoke-previous]
+  client-credentials list -client <id>
+  client-credentials revoke -client <id> -credential <id>`
+
+// client-credentials issues, lists and revokes the signing credentials of a
+// client. Issuing a credential starts the grace period of the previous ones.
+// Changes are audited like those made through the admin API, with the actor
+// "cli:<user>".
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	clientID := flags.String("client", "", "client ID")
+	credentialID := flags.String("credential", "", "credential ID (revoke)")
+	expiresIn := flags.Duration("expires-in", 0, "lifetime of the new credential, 0 for no expiry (issue)")
+	revokePrevious := flags.Bool("revoke-previous", false, "stop the previous credentials at once instead of after the grace period (issue)")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	clientUUID, err := uuid.Parse(*clientID)
+	if err != nil {
+		log.Fatalf("Invalid client ID: %v\n%s", err, usage)
+	}
+
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(cfg.Secrets.CurrentKeyID, cfg.Secrets.MasterKeys)
+	if err != nil {
+		log.Fatalf("Failed to load master keys: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	pool := db.GetPool()
+	transactor := repository.NewPostgresTransactor(pool)
+	clientService := services.NewClientService(transactor, repository.NewPostgresClientRepository(pool),
+		repository.NewPostgresClientSuspensionRepository(pool), repository.NewPostgresClientCredentialRepository(pool),
+		keyring, cfg.Clients.CredentialGracePeriod)
+	// Credential management needs none of the wallet services.
+	adminService := services.NewAdminService(transactor, clientService, nil, nil, nil,
+		services.NewAuditService(repository.NewPostgresAuditRepository(pool)))
+
+	actor := "cli"
+	if user := os.Getenv("USER"); user != "" {
+		actor += ":" + user
+	}
+
+	switch os.Args[1] {
+	case "issue":
+		var expiresAt *time.Time
+		if *expiresIn > 0 {
+			t := time.Now().Add(*expiresIn)
+			expiresAt = &t
+		}
+
+		credential, err := adminService.ResetCredentials(ctx, actor, clientUUID, expiresAt, *revokePrevious)
+		if err != nil {
+			log.Fatalf("Failed to issue credential: %v", err)
+		}
+		// The secret is shown only this once.
+		fmt.Printf("credential: %s\nsecret:     %s\n", credential.ID, string(credential.Secret))
+		if !*revokePrevious {
+			log.Printf("Previous credentials stay valid for %s", cfg.Clients.CredentialGracePeriod)
+		}
+	case "list":
+		credentials, err := adminService.ListCredentials(ctx, clientUUID)
+		if err != nil {
+			log.Fatalf("Failed to list credentials: %v", err)
+		}
+		now := time.Now()
+		for _, c := range credentials {
+			expires := "never"
+			if c.ExpiresAt != nil {
+				expires = c.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s  not before %s  expires %s  valid %t\n", c.ID, c.NotBefore.Format(time.RFC3339), expires, c.ValidAt(now))
+		}
+	case "revoke":
+		credentialUUID, err := uuid.Parse(*credentialID)
+		if err != nil {
+			log.Fatalf("Invalid credential ID: %v\n%s", err, usage)
+		}
+		if err := adminService.RevokeCredential(ctx, actor, clientUUID, credentialUUID); err != nil {
+			log.Fatalf("Failed to revoke credential: %v", err)
+		}
+		log.Printf("Revoked credential %s", credentialUUID)
+	default:
+		log.Fatal(usage)
+	}
+}
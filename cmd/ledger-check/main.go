@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	_ "time/tzdata"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/database"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// ledger-check verifies that the ledger balances and that every wallet's
+// cached balance equals the sum of its postings. It exits with status 1 when
+// a mismatch is found.
+func main() {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ledgerService := services.NewLedgerService(repository.NewPostgresLedgerRepository(db.GetPool()))
+	mismatches, err := ledgerService.VerifyWalletBalances(ctx)
+	if err != nil {
+		log.Fatalf("Ledger check failed: %v", err)
+	}
+
+	for _, m := range mismatches {
+		log.Printf("Wallet %s: balance %s, ledger balance %s", m.WalletID, m.Balance, m.LedgerBalance)
+	}
+	if len(mismatches) > 0 {
+		log.Fatalf("Found %d wallets whose balance does not match the ledger", len(mismatches))
+	}
+
+	log.Println("Ledger is consistent")
+}
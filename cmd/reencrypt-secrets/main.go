@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	_ "time/tzdata"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/database"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// reencrypt-secrets re-encrypts every client secret with the current master
+// key. To rotate the key, add the new key next to the old one, make it
+// current, run this command and then remove the old key.
+func main() {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(cfg.Secrets.CurrentKeyID, cfg.Secrets.MasterKeys)
+	if err != nil {
+		log.Fatalf("Failed to load master keys: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	pool := db.GetPool()
+	clientService := services.NewClientService(repository.NewPostgresTransactor(pool), repository.NewPostgresClientRepository(pool),
+		repository.NewPostgresClientSuspensionRepository(pool), repository.NewPostgresClientCredentialRepository(pool),
+		keyring, cfg.Clients.CredentialGracePeriod)
+
+	reencrypted, err := clientService.ReencryptSecrets(ctx)
+	if err != nil {
+		log.Fatalf("Re-encrypted %d credential secrets before failing: %v", reencrypted, err)
+	}
+
+	log.Printf("Re-encrypted %d credential secrets with master key %q", reencrypted, keyring.CurrentKeyID())
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	_ "time/tzdata"
 
 	"github.com/mabduqayum/ewallet/internal/config"
 	"github.com/mabduqayum/ewallet/internal/database"
@@ -26,7 +27,7 @@ func main() {
 		log.Fatalf("Failed to run database migrations: %v", err)
 	}
 
-	if err := scripts.SeedData(ctx, db.GetPool()); err != nil {
+	if err := scripts.SeedData(ctx, db.GetPool(), cfg); err != nil {
 		log.Fatalf("Failed to seed data: %v", err)
 	}
 
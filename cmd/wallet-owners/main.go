@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	_ "time/tzdata"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/database"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
+
+	"github.com/google/uuid"
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const usage = `usage:
+  wallet-owners unowned
+  wallet-owners assign -client <id> [-wallets <file>]`
+
+// wallet-owners assigns owners to the wallets that predate wallet ownership
+// in bulk. unowned prints the IDs of the wallets still without an owner;
+// assign gives the wallets listed in the file, one ID per line, or every
+// unowned wallet if no file is given, to the client. A run assigns either
+// all wallets or none. Assignments are audited like those made through the
+// admin API, with the actor "cli:<user>".
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	clientID := flags.String("client", "", "owner client ID (assign)")
+	walletsFile := flags.String("wallets", "", "file of wallet IDs, one per line; all unowned wallets if empty (assign)")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	keyring, err := secrets.NewKeyring(cfg.Secrets.CurrentKeyID, cfg.Secrets.MasterKeys)
+	if err != nil {
+		log.Fatalf("Failed to load master keys: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := database.New(ctx, &cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	pool := db.GetPool()
+	transactor := repository.NewPostgresTransactor(pool)
+	clientService := services.NewClientService(transactor, repository.NewPostgresClientRepository(pool),
+		repository.NewPostgresClientSuspensionRepository(pool), repository.NewPostgresClientCredentialRepository(pool),
+		keyring, cfg.Clients.CredentialGracePeriod)
+	// Assigning owners moves no money, so the wallet service needs none of
+	// the ledger, limits or customer services.
+	walletService := services.NewWalletService(transactor, repository.NewPostgresWalletRepository(pool), nil, nil, nil, nil, nil, nil)
+	adminService := services.NewAdminService(transactor, clientService, walletService, nil, nil,
+		services.NewAuditService(repository.NewPostgresAuditRepository(pool)))
+
+	actor := "cli"
+	if user := os.Getenv("USER"); user != "" {
+		actor += ":" + user
+	}
+
+	switch os.Args[1] {
+	case "unowned":
+		walletIDs, err := walletService.GetUnownedWalletIDs(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list unowned wallets: %v", err)
+		}
+		for _, walletID := range walletIDs {
+			fmt.Println(walletID)
+		}
+	case "assign":
+		clientUUID, err := uuid.Parse(*clientID)
+		if err != nil {
+			log.Fatalf("Invalid client ID: %v\n%s", err, usage)
+		}
+
+		var walletIDs []uuid.UUID
+		if *walletsFile == "" {
+			walletIDs, err = walletService.GetUnownedWalletIDs(ctx)
+		} else {
+			walletIDs, err = readWalletIDs(*walletsFile)
+		}
+		if err != nil {
+			log.Fatalf("Failed to read wallet IDs: %v", err)
+		}
+
+		if err := adminService.AssignWalletOwners(ctx, actor, clientUUID, walletIDs); err != nil {
+			log.Fatalf("Failed to assign wallet owners, none were assigned: %v", err)
+		}
+		log.Printf("Assigned %d wallets to client %s", len(walletIDs), clientUUID)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// readWalletIDs reads one wallet ID per line. Blank lines and lines starting
+// with # are skipped.
+func readWalletIDs(name string) ([]uuid.UUID, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var walletIDs []uuid.UUID
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		walletID, err := uuid.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		walletIDs = append(walletIDs, walletID)
+	}
+	return walletIDs, scanner.Err()
+}
@@ -2,17 +2,29 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Wallet   WalletConfig
-	Logging  LoggingConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	Wallet         WalletConfig
+	Idempotency    IdempotencyConfig
+	Clients        ClientsConfig
+	Customers      CustomersConfig
+	Identification IdentificationConfig
+	Holds          HoldsConfig
+	Auth           AuthConfig
+	Secrets        SecretsConfig
+	Admin          AdminConfig
+	Logging        LoggingConfig
 }
 
 type ServerConfig struct {
@@ -52,8 +64,118 @@ func (d DatabaseConfig) ConnectionString() string {
 }
 
 type WalletConfig struct {
+	// Currencies are the ISO 4217 codes partners may open wallets in.
+	Currencies []string
+	// UnidentifiedLimit and IdentifiedLimit are the maximum balances per wallet type.
 	UnidentifiedLimit float64
 	IdentifiedLimit   float64
+	// CurrencyLimits overrides the maximum balance per currency and wallet
+	// type, e.g. {"USD": {"UNIDENTIFIED": 1000}}.
+	CurrencyLimits map[string]map[string]float64
+	// TurnoverLimits caps incoming money per wallet type, e.g.
+	// {"UNIDENTIFIED": {...}}. The regulatory limits of UNIDENTIFIED wallets
+	// apply unless the file overrides them.
+	TurnoverLimits map[string]TurnoverLimitsConfig
+	// Timezone is the IANA name of the timezone that calendar periods
+	// (days, months, ...) are computed in. Unlike the limits, it is not
+	// reloaded; changing it requires a restart.
+	Timezone string
+	Location *time.Location `mapstructure:"-"`
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a stored response is replayed for retries with the same key.
+	TTL time.Duration
+	// LockTTL is how long a reserved key stays locked before its request
+	// starts, so that the key can be retried after a crash. A running request
+	// keeps the key locked until it finishes.
+	LockTTL         time.Duration
+	CleanupInterval time.Duration
+}
+
+type ClientsConfig struct {
+	// ReactivationInterval is how often scheduled client reactivations are applied.
+	ReactivationInterval time.Duration
+	// CredentialGracePeriod is how long a client's previous credentials keep
+	// working after a new one is issued.
+	CredentialGracePeriod time.Duration
+}
+
+type CustomersConfig struct {
+	// DefaultCountryCode is the calling code, without +, assumed for phone
+	// numbers given in national format.
+	DefaultCountryCode string
+}
+
+type IdentificationConfig struct {
+	// ExpiryCheckInterval is how often wallets with expired identification
+	// documents are downgraded.
+	ExpiryCheckInterval time.Duration
+}
+
+type HoldsConfig struct {
+	// TTL is how long a hold reserves money unless it is captured or released.
+	TTL time.Duration
+	// ExpiryCheckInterval is how often holds past their TTL are marked expired.
+	ExpiryCheckInterval time.Duration
+}
+
+type AuthConfig struct {
+	// ClockSkew is how far the X-Timestamp of an HMAC-SHA256 request may be
+	// from the server clock.
+	ClockSkew            time.Duration
+	NonceCleanupInterval time.Duration
+}
+
+type SecretsConfig struct {
+	// CurrentKeyID names the master key that new client secrets are encrypted with.
+	CurrentKeyID string
+	// MasterKeys are base64-encoded 32-byte AES keys by ID. Keys that are no
+	// longer current are kept until every secret has been re-encrypted.
+	MasterKeys map[string]string
+}
+
+type AdminConfig struct {
+	// Tokens are the bearer tokens of the admin API by admin name. The name is
+	// recorded as the actor of audited actions.
+	Tokens map[string]string
+}
+
+// TurnoverLimitsConfig caps top-ups and incoming transfers. Zero disables a limit.
+type TurnoverLimitsConfig struct {
+	MaxOperation float64
+	Daily        float64
+	Monthly      float64
+}
+
+func (w *WalletConfig) loadLocation() error {
+	location, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid wallet timezone: %w", err)
+	}
+	w.Location = location
+	return nil
+}
+
+// validateDurations rejects TTLs and job intervals that are not positive;
+// time.NewTicker panics on those.
+func (c *Config) validateDurations() error {
+	durations := map[string]time.Duration{
+		"idempotency.ttl":                    c.Idempotency.TTL,
+		"idempotency.lockTTL":                c.Idempotency.LockTTL,
+		"idempotency.cleanupInterval":        c.Idempotency.CleanupInterval,
+		"clients.reactivationInterval":       c.Clients.ReactivationInterval,
+		"identification.expiryCheckInterval": c.Identification.ExpiryCheckInterval,
+		"holds.ttl":                          c.Holds.TTL,
+		"holds.expiryCheckInterval":          c.Holds.ExpiryCheckInterval,
+		"auth.nonceCleanupInterval":          c.Auth.NonceCleanupInterval,
+	}
+	for name, d := range durations {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, d)
+		}
+	}
+	return nil
 }
 
 type LoggingConfig struct {
@@ -65,6 +187,25 @@ func LoadConfig(env string) (*Config, error) {
 	viper.AddConfigPath("./internal/config")
 	viper.SetConfigType("yaml")
 
+	viper.SetDefault("wallet.currencies", []string{"TJS"})
+	viper.SetDefault("wallet.unidentifiedLimit", 10_000)
+	viper.SetDefault("wallet.identifiedLimit", 100_000)
+	viper.SetDefault("wallet.turnoverLimits.unidentified.maxOperation", 5_000)
+	viper.SetDefault("wallet.turnoverLimits.unidentified.daily", 10_000)
+	viper.SetDefault("wallet.turnoverLimits.unidentified.monthly", 30_000)
+	viper.SetDefault("wallet.timezone", "Asia/Dushanbe")
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+	viper.SetDefault("idempotency.lockTTL", time.Minute)
+	viper.SetDefault("idempotency.cleanupInterval", time.Hour)
+	viper.SetDefault("clients.reactivationInterval", time.Minute)
+	viper.SetDefault("clients.credentialGracePeriod", 7*24*time.Hour)
+	viper.SetDefault("customers.defaultCountryCode", "992")
+	viper.SetDefault("identification.expiryCheckInterval", time.Hour)
+	viper.SetDefault("holds.ttl", 7*24*time.Hour)
+	viper.SetDefault("holds.expiryCheckInterval", time.Minute)
+	viper.SetDefault("auth.clockSkew", 5*time.Minute)
+	viper.SetDefault("auth.nonceCleanupInterval", time.Hour)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
@@ -74,10 +215,54 @@ func LoadConfig(env string) (*Config, error) {
 		return nil, fmt.Errorf("unable to decode into struct: %w", err)
 	}
 
+	if err := config.Wallet.loadLocation(); err != nil {
+		return nil, err
+	}
+	if err := config.validateDurations(); err != nil {
+		return nil, err
+	}
+
 	// Override with environment variables if they exist
 	if dbPassword := os.Getenv("DB_PASSWORD"); dbPassword != "" {
 		config.Database.Password = dbPassword
 	}
+	if keyID := os.Getenv("SECRETS_MASTER_KEY_ID"); keyID != "" {
+		config.Secrets.CurrentKeyID = keyID
+	}
+	// Viper lower-cases map keys, so key IDs are compared in lower case.
+	config.Secrets.CurrentKeyID = strings.ToLower(config.Secrets.CurrentKeyID)
+	if masterKey := os.Getenv("SECRETS_MASTER_KEY"); masterKey != "" {
+		if config.Secrets.MasterKeys == nil {
+			config.Secrets.MasterKeys = make(map[string]string)
+		}
+		config.Secrets.MasterKeys[config.Secrets.CurrentKeyID] = masterKey
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		if config.Admin.Tokens == nil {
+			config.Admin.Tokens = make(map[string]string)
+		}
+		config.Admin.Tokens["admin"] = adminToken
+	}
 
 	return &config, nil
 }
+
+// WatchWalletConfig calls onChange with the re-read wallet section every time
+// the config file loaded by LoadConfig changes. The whole config is decoded so
+// that keys missing from the file fall back to their defaults.
+func WatchWalletConfig(onChange func(WalletConfig)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var config Config
+		if err := viper.Unmarshal(&config); err != nil {
+			log.Printf("Failed to reload wallet config from %s: %v", e.Name, err)
+			return
+		}
+		wallet := config.Wallet
+		if err := wallet.loadLocation(); err != nil {
+			log.Printf("Failed to reload wallet config from %s: %v", e.Name, err)
+			return
+		}
+		onChange(wallet)
+	})
+	viper.WatchConfig()
+}
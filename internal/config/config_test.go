@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadConfigDefaultsWalletLimits(t *testing.T) {
+	// The production and staging files are empty; everything the server
+	// needs to accept money, and the regulatory limits, must come from the
+	// defaults.
+	for _, env := range []string{"production", "staging"} {
+		t.Run(env, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			viper.AddConfigPath(".")
+
+			cfg, err := LoadConfig(env)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if cfg.Wallet.UnidentifiedLimit != 10_000 || cfg.Wallet.IdentifiedLimit != 100_000 {
+				t.Errorf("got wallet limits %v and %v; want 10000 and 100000", cfg.Wallet.UnidentifiedLimit, cfg.Wallet.IdentifiedLimit)
+			}
+			want := TurnoverLimitsConfig{MaxOperation: 5_000, Daily: 10_000, Monthly: 30_000}
+			if got := cfg.Wallet.TurnoverLimits["unidentified"]; got != want {
+				t.Errorf("got unidentified turnover limits %+v; want %+v", got, want)
+			}
+			if len(cfg.Wallet.Currencies) == 0 || cfg.Wallet.Location == nil {
+				t.Errorf("expected default currencies and timezone, got %+v", cfg.Wallet)
+			}
+		})
+	}
+}
@@ -1 +1,19 @@
 package constants
+
+const (
+	// ClientLocalsKey is the fiber.Ctx locals key holding the authenticated *models.Client.
+	ClientLocalsKey = "client"
+	// AdminLocalsKey is the fiber.Ctx locals key holding the name of the admin
+	// authenticated by AdminAuthMiddleware.
+	AdminLocalsKey = "admin"
+
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength  = 255
+
+	// TimestampHeader carries the Unix time in seconds at which an
+	// HMAC-SHA256 request was signed.
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+	MaxNonceLength  = 128
+)
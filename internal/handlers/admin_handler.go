@@ -0,0 +1,581 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultClientPageSize = 50
+	maxClientPageSize     = 200
+)
+
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// adminClient is the admin view of a client, which includes the API key the
+// partner sends as X-UserId.
+type adminClient struct {
+	*models.Client
+	ApiKey string `json:"api_key"`
+}
+
+func newAdminClient(client *models.Client) adminClient {
+	return adminClient{Client: client, ApiKey: client.ApiKey}
+}
+
+func (h *AdminHandler) CreateClient(c *fiber.Ctx) error {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	var scopes []models.Scope
+	if req.Scopes != nil {
+		var err error
+		if scopes, err = models.ParseScopes(req.Scopes); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	client, credential, err := h.adminService.CreateClient(c.UserContext(), middleware.CurrentAdmin(c), req.Name, scopes)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to create client")
+	}
+
+	// The secret is never returned again.
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"client":     newAdminClient(client),
+		"credential": credential,
+		"secret":     string(credential.Secret),
+	})
+}
+
+func (h *AdminHandler) ListClients(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultClientPageSize)
+	offset := c.QueryInt("offset", 0)
+	if limit < 1 || limit > maxClientPageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Limit must be between 1 and 200"})
+	}
+	if offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Offset must not be negative"})
+	}
+
+	clients, total, err := h.adminService.ListClients(c.UserContext(), limit, offset)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to list clients")
+	}
+
+	page := make([]adminClient, 0, len(clients))
+	for _, client := range clients {
+		page = append(page, newAdminClient(client))
+	}
+	return c.JSON(fiber.Map{"clients": page, "total": total, "limit": limit, "offset": offset})
+}
+
+func (h *AdminHandler) GetClient(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	client, err := h.adminService.GetClient(c.UserContext(), clientID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to get client")
+	}
+	return c.JSON(newAdminClient(client))
+}
+
+func (h *AdminHandler) RenameClient(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	client, err := h.adminService.RenameClient(c.UserContext(), middleware.CurrentAdmin(c), clientID, req.Name)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to rename client")
+	}
+	return c.JSON(newAdminClient(client))
+}
+
+func (h *AdminHandler) SetClientScopes(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	var req struct {
+		Scopes []string `json:"scopes"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	scopes, err := models.ParseScopes(req.Scopes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	client, err := h.adminService.SetClientScopes(c.UserContext(), middleware.CurrentAdmin(c), clientID, scopes)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to set client scopes")
+	}
+	return c.JSON(newAdminClient(client))
+}
+
+func (h *AdminHandler) SetClientSignatureScheme(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	var req struct {
+		Scheme string `json:"scheme"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	client, err := h.adminService.SetClientSignatureScheme(c.UserContext(), middleware.CurrentAdmin(c), clientID, models.SignatureScheme(req.Scheme))
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to set client signature scheme")
+	}
+	return c.JSON(newAdminClient(client))
+}
+
+func (h *AdminHandler) ActivateClient(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	if err := h.adminService.ActivateClient(c.UserContext(), middleware.CurrentAdmin(c), clientID); err != nil {
+		return adminErrorResponse(c, err, "Failed to activate client")
+	}
+	return c.JSON(fiber.Map{"message": "Client activated"})
+}
+
+func (h *AdminHandler) DeactivateClient(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	var req struct {
+		Reason       string     `json:"reason"`
+		ReactivateAt *time.Time `json:"reactivateAt"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	suspension, err := h.adminService.DeactivateClient(c.UserContext(), middleware.CurrentAdmin(c), clientID, req.Reason, req.ReactivateAt)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to deactivate client")
+	}
+	return c.JSON(suspension)
+}
+
+func (h *AdminHandler) ResetCredentials(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	var req struct {
+		ExpiresAt      *time.Time `json:"expiresAt"`
+		RevokePrevious bool       `json:"revokePrevious"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	credential, err := h.adminService.ResetCredentials(c.UserContext(), middleware.CurrentAdmin(c), clientID, req.ExpiresAt, req.RevokePrevious)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to reset credentials")
+	}
+
+	// The secret is never returned again.
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"credential": credential,
+		"secret":     string(credential.Secret),
+	})
+}
+
+func (h *AdminHandler) ListCredentials(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	credentials, err := h.adminService.ListCredentials(c.UserContext(), clientID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to list credentials")
+	}
+	if credentials == nil {
+		credentials = []*models.ClientCredential{}
+	}
+	return c.JSON(fiber.Map{"credentials": credentials})
+}
+
+func (h *AdminHandler) RevokeCredential(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+	credentialID, err := uuid.Parse(c.Params("credentialID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid credential ID"})
+	}
+
+	if err := h.adminService.RevokeCredential(c.UserContext(), middleware.CurrentAdmin(c), clientID, credentialID); err != nil {
+		return adminErrorResponse(c, err, "Failed to revoke credential")
+	}
+	return c.JSON(fiber.Map{"message": "Credential revoked"})
+}
+
+func (h *AdminHandler) GetAuditTrail(c *fiber.Ctx) error {
+	clientID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid client ID"})
+	}
+
+	entries, err := h.adminService.GetAuditTrail(c.UserContext(), clientID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to get audit trail")
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+	return c.JSON(fiber.Map{"entries": entries})
+}
+
+func (h *AdminHandler) GetWalletAccess(c *fiber.Ctx) error {
+	walletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	wallet, clientIDs, err := h.adminService.GetWalletAccess(c.UserContext(), walletID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to get wallet access")
+	}
+	if clientIDs == nil {
+		clientIDs = []uuid.UUID{}
+	}
+	var ownerID *uuid.UUID
+	if wallet.ClientID != uuid.Nil {
+		ownerID = &wallet.ClientID
+	}
+	return c.JSON(fiber.Map{"wallet_id": wallet.ID, "owner_id": ownerID, "granted_client_ids": clientIDs})
+}
+
+func (h *AdminHandler) AssignWalletOwner(c *fiber.Ctx) error {
+	walletID, clientID, message := walletAccessParams(c)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	if err := h.adminService.AssignWalletOwner(c.UserContext(), middleware.CurrentAdmin(c), walletID, clientID); err != nil {
+		return adminErrorResponse(c, err, "Failed to assign wallet owner")
+	}
+	return c.JSON(fiber.Map{"message": "Wallet owner assigned"})
+}
+
+func (h *AdminHandler) GrantWalletAccess(c *fiber.Ctx) error {
+	walletID, clientID, message := walletAccessParams(c)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	if err := h.adminService.GrantWalletAccess(c.UserContext(), middleware.CurrentAdmin(c), walletID, clientID); err != nil {
+		return adminErrorResponse(c, err, "Failed to grant wallet access")
+	}
+	return c.JSON(fiber.Map{"message": "Wallet access granted"})
+}
+
+func (h *AdminHandler) RevokeWalletAccess(c *fiber.Ctx) error {
+	walletID, clientID, message := walletAccessParams(c)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	if err := h.adminService.RevokeWalletAccess(c.UserContext(), middleware.CurrentAdmin(c), walletID, clientID); err != nil {
+		return adminErrorResponse(c, err, "Failed to revoke wallet access")
+	}
+	return c.JSON(fiber.Map{"message": "Wallet access revoked"})
+}
+
+func (h *AdminHandler) GetWalletAuditTrail(c *fiber.Ctx) error {
+	walletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	entries, err := h.adminService.GetWalletAuditTrail(c.UserContext(), walletID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to get audit trail")
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+	return c.JSON(fiber.Map{"entries": entries})
+}
+
+func (h *AdminHandler) ChangeWalletStatus(c *fiber.Ctx) error {
+	walletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	var req struct {
+		Status models.WalletStatus `json:"status"`
+		Reason string              `json:"reason"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	change, err := h.adminService.ChangeWalletStatus(c.UserContext(), middleware.CurrentAdmin(c), walletID, req.Status, req.Reason)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to change wallet status")
+	}
+	return c.JSON(change)
+}
+
+func (h *AdminHandler) GetWalletStatusHistory(c *fiber.Ctx) error {
+	walletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	changes, err := h.adminService.GetWalletStatusHistory(c.UserContext(), walletID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to get wallet status history")
+	}
+	if changes == nil {
+		changes = []*models.WalletStatusChange{}
+	}
+	return c.JSON(fiber.Map{"changes": changes})
+}
+
+func (h *AdminHandler) ReverseTransaction(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	var req struct {
+		// Amount defaults to whatever has not been reversed yet.
+		Amount *models.Money `json:"amount"`
+		Reason string        `json:"reason"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	var amount models.Money
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+		}
+		amount = *req.Amount
+	}
+
+	reversals, err := h.adminService.ReverseTransaction(c.UserContext(), middleware.CurrentAdmin(c), transactionID, amount, req.Reason)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to reverse transaction")
+	}
+	return c.JSON(fiber.Map{"reversals": reversals})
+}
+
+func (h *AdminHandler) CompleteTransaction(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	transaction, err := h.adminService.CompleteTransaction(c.UserContext(), middleware.CurrentAdmin(c), transactionID)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to complete transaction")
+	}
+	return c.JSON(transaction)
+}
+
+func (h *AdminHandler) FailTransaction(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	transaction, err := h.adminService.FailTransaction(c.UserContext(), middleware.CurrentAdmin(c), transactionID, req.Reason)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to fail transaction")
+	}
+	return c.JSON(transaction)
+}
+
+func (h *AdminHandler) ListIdentifications(c *fiber.Ctx) error {
+	status := models.IdentificationState(c.Query("status", string(models.IdentificationPending)))
+	if !status.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid identification status"})
+	}
+	limit := c.QueryInt("limit", defaultClientPageSize)
+	offset := c.QueryInt("offset", 0)
+	if limit < 1 || limit > maxClientPageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Limit must be between 1 and 200"})
+	}
+	if offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Offset must not be negative"})
+	}
+
+	identifications, err := h.adminService.ListIdentifications(c.UserContext(), status, limit, offset)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to list identifications")
+	}
+	if identifications == nil {
+		identifications = []*models.Identification{}
+	}
+	return c.JSON(fiber.Map{"identifications": identifications, "limit": limit, "offset": offset})
+}
+
+func (h *AdminHandler) ApproveIdentification(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid identification ID"})
+	}
+
+	identification, err := h.adminService.ApproveIdentification(c.UserContext(), middleware.CurrentAdmin(c), id)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to approve identification")
+	}
+	return c.JSON(identification)
+}
+
+func (h *AdminHandler) RejectIdentification(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid identification ID"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	identification, err := h.adminService.RejectIdentification(c.UserContext(), middleware.CurrentAdmin(c), id, req.Reason)
+	if err != nil {
+		return adminErrorResponse(c, err, "Failed to reject identification")
+	}
+	return c.JSON(identification)
+}
+
+// walletAccessParams parses the wallet and client IDs from the path. A
+// non-empty message means one of them is invalid.
+func walletAccessParams(c *fiber.Ctx) (walletID, clientID uuid.UUID, message string) {
+	walletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, "Invalid wallet ID"
+	}
+	if clientID, err = uuid.Parse(c.Params("clientID")); err != nil {
+		return uuid.Nil, uuid.Nil, "Invalid client ID"
+	}
+	return walletID, clientID, ""
+}
+
+func adminErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrClientNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Client not found"})
+	case errors.Is(err, services.ErrCredentialNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Credential not found"})
+	case errors.Is(err, services.ErrWalletNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Wallet not found"})
+	case errors.Is(err, services.ErrWalletAccessNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrIdentificationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Identification not found"})
+	case errors.Is(err, services.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+	case errors.Is(err, services.ErrClientAlreadySuspended),
+		errors.Is(err, services.ErrClientNotSuspended),
+		errors.Is(err, services.ErrWalletOwnedByClient),
+		errors.Is(err, services.ErrWalletAlreadyOwned),
+		errors.Is(err, services.ErrIdentificationNotPending),
+		errors.Is(err, services.ErrDocumentExpired),
+		errors.Is(err, models.ErrInvalidStatusTransition),
+		errors.Is(err, services.ErrWalletNotEmpty),
+		errors.Is(err, models.ErrTransactionNotReversible),
+		errors.Is(err, models.ErrTransactionAlreadyReversed),
+		errors.Is(err, models.ErrTransactionNotCompleted),
+		errors.Is(err, models.ErrInvalidTransactionTransition),
+		errors.Is(err, services.ErrTransactionStatusChanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrClientNameRequired),
+		errors.Is(err, services.ErrInvalidSignatureScheme),
+		errors.Is(err, services.ErrSuspensionReasonRequired),
+		errors.Is(err, services.ErrInvalidReactivationTime),
+		errors.Is(err, services.ErrInvalidCredentialExpiry),
+		errors.Is(err, services.ErrRejectionReasonRequired),
+		errors.Is(err, services.ErrInvalidWalletStatus),
+		errors.Is(err, services.ErrStatusReasonRequired),
+		errors.Is(err, services.ErrReversalReasonRequired),
+		errors.Is(err, services.ErrFailureReasonRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, models.ErrReversalExceedsAmount),
+		errors.Is(err, models.ErrInsufficientFunds),
+		errors.Is(err, models.ErrBalanceLimitExceeded),
+		errors.Is(err, models.ErrCreditsNotAllowed),
+		errors.Is(err, models.ErrDebitsNotAllowed):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
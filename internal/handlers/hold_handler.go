@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type HoldHandler struct {
+	holdService *services.HoldService
+}
+
+func NewHoldHandler(holdService *services.HoldService) *HoldHandler {
+	return &HoldHandler{holdService: holdService}
+}
+
+func (h *HoldHandler) Place(c *fiber.Ctx) error {
+	var req struct {
+		WalletID    string       `json:"walletID"`
+		Amount      models.Money `json:"amount"`
+		Description string       `json:"description"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+	}
+
+	hold, err := h.holdService.Place(c.UserContext(), middleware.CurrentClient(c), walletID, req.Amount, req.Description)
+	if err != nil {
+		return holdErrorResponse(c, err, "Failed to place hold")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(hold)
+}
+
+func (h *HoldHandler) Capture(c *fiber.Ctx) error {
+	var req struct {
+		HoldID string `json:"holdID"`
+		// Amount defaults to the whole hold.
+		Amount *models.Money `json:"amount"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	holdID, err := uuid.Parse(req.HoldID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hold ID"})
+	}
+
+	var amount models.Money
+	if req.Amount != nil {
+		if !req.Amount.IsPositive() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+		}
+		amount = *req.Amount
+	}
+
+	hold, err := h.holdService.Capture(c.UserContext(), middleware.CurrentClient(c), holdID, amount)
+	if err != nil {
+		return holdErrorResponse(c, err, "Failed to capture hold")
+	}
+
+	return c.JSON(hold)
+}
+
+func (h *HoldHandler) Release(c *fiber.Ctx) error {
+	holdID, message := parseHoldID(c)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	hold, err := h.holdService.Release(c.UserContext(), middleware.CurrentClient(c), holdID)
+	if err != nil {
+		return holdErrorResponse(c, err, "Failed to release hold")
+	}
+
+	return c.JSON(hold)
+}
+
+func (h *HoldHandler) GetStatus(c *fiber.Ctx) error {
+	holdID, message := parseHoldID(c)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": message})
+	}
+
+	hold, err := h.holdService.Get(c.UserContext(), middleware.CurrentClient(c), holdID)
+	if err != nil {
+		return holdErrorResponse(c, err, "Failed to get hold")
+	}
+
+	return c.JSON(hold)
+}
+
+// parseHoldID reads the hold ID from the request body. A non-empty message
+// means the body or the ID is invalid.
+func parseHoldID(c *fiber.Ctx) (holdID uuid.UUID, message string) {
+	var req struct {
+		HoldID string `json:"holdID"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return uuid.Nil, "Invalid request body"
+	}
+
+	holdID, err := uuid.Parse(req.HoldID)
+	if err != nil {
+		return uuid.Nil, "Invalid hold ID"
+	}
+	return holdID, ""
+}
+
+func holdErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrHoldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Hold not found"})
+	case errors.Is(err, models.ErrHoldNotActive):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, models.ErrCaptureExceedsHold):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return walletErrorResponse(c, err, message)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type IdentificationHandler struct {
+	identificationService *services.IdentificationService
+}
+
+func NewIdentificationHandler(identificationService *services.IdentificationService) *IdentificationHandler {
+	return &IdentificationHandler{identificationService: identificationService}
+}
+
+func (h *IdentificationHandler) Submit(c *fiber.Ctx) error {
+	var req struct {
+		WalletID          string `json:"walletID"`
+		DocumentType      string `json:"documentType"`
+		DocumentNumber    string `json:"documentNumber"`
+		DocumentExpiresAt string `json:"documentExpiresAt"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	expiresAt, err := time.Parse(time.DateOnly, req.DocumentExpiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Document expiry must be YYYY-MM-DD"})
+	}
+
+	identification, err := h.identificationService.Submit(c.UserContext(), middleware.CurrentClient(c), walletID,
+		models.DocumentType(req.DocumentType), req.DocumentNumber, expiresAt)
+	if err != nil {
+		return identificationErrorResponse(c, err, "Failed to submit identification")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(identification)
+}
+
+func (h *IdentificationHandler) GetStatus(c *fiber.Ctx) error {
+	var req struct {
+		WalletID string `json:"walletID"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	identification, err := h.identificationService.GetLatest(c.UserContext(), middleware.CurrentClient(c), walletID)
+	if err != nil {
+		return identificationErrorResponse(c, err, "Failed to get identification status")
+	}
+
+	return c.JSON(identification)
+}
+
+func identificationErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrIdentificationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Identification not found"})
+	case errors.Is(err, services.ErrInvalidDocumentType),
+		errors.Is(err, services.ErrDocumentNumberRequired),
+		errors.Is(err, services.ErrDocumentExpired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrIdentificationPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return walletErrorResponse(c, err, message)
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TransactionHandler struct {
+	transactionService *services.TransactionService
+}
+
+func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
+	return &TransactionHandler{transactionService: transactionService}
+}
+
+func (h *TransactionHandler) ListTransactions(c *fiber.Ctx) error {
+	var req struct {
+		WalletID  string        `json:"walletID"`
+		Cursor    string        `json:"cursor"`
+		Limit     *int          `json:"limit"`
+		Types     []string      `json:"types"`
+		MinAmount *models.Money `json:"minAmount"`
+		MaxAmount *models.Money `json:"maxAmount"`
+		From      *time.Time    `json:"from"`
+		To        *time.Time    `json:"to"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	// An omitted limit gets the default page size.
+	limit := models.DefaultTransactionPageSize
+	if req.Limit != nil {
+		if *req.Limit < 1 || *req.Limit > models.MaxTransactionPageSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Limit must be between 1 and 200"})
+		}
+		limit = *req.Limit
+	}
+
+	filter := models.TransactionFilter{
+		WalletID:  walletID,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+		From:      req.From,
+		To:        req.To,
+		Limit:     limit,
+	}
+
+	for _, t := range req.Types {
+		transactionType := models.TransactionType(t)
+		if !transactionType.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction type: " + t})
+		}
+		filter.Types = append(filter.Types, transactionType)
+	}
+
+	if req.Cursor != "" {
+		filter.After, err = models.DecodeTransactionCursor(req.Cursor)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cursor"})
+		}
+	}
+
+	page, err := h.transactionService.ListTransactions(c.UserContext(), middleware.CurrentClient(c), filter)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to list transactions")
+	}
+
+	return c.JSON(page)
+}
+
+func (h *TransactionHandler) GetStats(c *fiber.Ctx) error {
+	var req struct {
+		WalletID string     `json:"walletID"`
+		Period   string     `json:"period"`
+		From     *time.Time `json:"from"`
+		To       *time.Time `json:"to"`
+		Timezone string     `json:"timezone"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
+	}
+
+	query := models.StatsQuery{
+		Period: models.StatsPeriod(req.Period),
+		From:   req.From,
+		To:     req.To,
+	}
+
+	if req.Timezone != "" {
+		query.Location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid timezone"})
+		}
+	}
+
+	report, err := h.transactionService.GetStats(c.UserContext(), middleware.CurrentClient(c), walletID, query)
+	if errors.Is(err, models.ErrInvalidStatsPeriod) || errors.Is(err, models.ErrInvalidStatsRange) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to get transaction stats")
+	}
+
+	// count and sum describe top-ups, as they did before per-type stats existed.
+	var count int
+	var sum models.Money
+	if topUps, ok := report.Stats[models.TransactionTypeTopUp]; ok {
+		count, sum = topUps.Count, topUps.Sum
+	}
+
+	return c.JSON(fiber.Map{
+		"count":    count,
+		"sum":      sum,
+		"from":     report.From,
+		"to":       report.To,
+		"timezone": report.Timezone,
+		"stats":    report.Stats,
+	})
+}
+
+// GetStatus looks up a transaction by its ID or by the client's own reference.
+func (h *TransactionHandler) GetStatus(c *fiber.Ctx) error {
+	var req struct {
+		TransactionID string `json:"transactionID"`
+		ExternalRef   string `json:"externalRef"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	client := middleware.CurrentClient(c)
+	var transaction *models.Transaction
+	switch {
+	case req.TransactionID != "" && req.ExternalRef != "":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Specify either transactionID or externalRef"})
+	case req.TransactionID != "":
+		transactionID, err := uuid.Parse(req.TransactionID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+		}
+		transaction, err = h.transactionService.GetClientTransaction(c.UserContext(), client, transactionID)
+		if err != nil {
+			return walletErrorResponse(c, err, "Failed to get transaction status")
+		}
+	case req.ExternalRef != "":
+		var err error
+		transaction, err = h.transactionService.GetTransactionByExternalRef(c.UserContext(), client, req.ExternalRef)
+		if err != nil {
+			return walletErrorResponse(c, err, "Failed to get transaction status")
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "transactionID or externalRef is required"})
+	}
+
+	return c.JSON(transaction)
+}
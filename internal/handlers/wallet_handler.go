@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,6 +20,75 @@ func NewWalletHandler(walletService *services.WalletService) *WalletHandler {
 	return &WalletHandler{walletService: walletService}
 }
 
+// maxExternalRefLength caps the partner's own wallet and transaction
+// references.
+const maxExternalRefLength = 255
+
+// parseExternalRef returns nil for an empty reference, or a message for
+// the client when the reference is too long.
+func parseExternalRef(ref string) (*string, string) {
+	if len(ref) > maxExternalRefLength {
+		return nil, "External reference must be at most 255 characters"
+	}
+	if ref == "" {
+		return nil, ""
+	}
+	return &ref, ""
+}
+
+func (h *WalletHandler) CreateWallet(c *fiber.Ctx) error {
+	var req struct {
+		Type              string `json:"type"`
+		Currency          string `json:"currency"`
+		ExternalRef       string `json:"externalRef"`
+		HolderPhone       string `json:"holderPhone"`
+		HolderName        string `json:"holderName"`
+		HolderDateOfBirth string `json:"holderDateOfBirth"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	externalRef, msg := parseExternalRef(req.ExternalRef)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	var holder *models.CustomerDetails
+	if req.HolderPhone != "" {
+		holder = &models.CustomerDetails{Phone: req.HolderPhone}
+		if req.HolderName != "" {
+			holder.Name = &req.HolderName
+		}
+		if req.HolderDateOfBirth != "" {
+			dateOfBirth, err := time.Parse(time.DateOnly, req.HolderDateOfBirth)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Holder date of birth must be YYYY-MM-DD"})
+			}
+			holder.DateOfBirth = &dateOfBirth
+		}
+	} else if req.HolderName != "" || req.HolderDateOfBirth != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Holder details require holderPhone"})
+	}
+
+	wallet, err := h.walletService.CreateWallet(c.UserContext(), middleware.CurrentClient(c), models.WalletType(req.Type), req.Currency, externalRef, holder)
+	switch {
+	case errors.Is(err, services.ErrInvalidWalletType),
+		errors.Is(err, services.ErrUnsupportedCurrency),
+		errors.Is(err, models.ErrInvalidPhone),
+		errors.Is(err, models.ErrAmbiguousPhone):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrDuplicateExternalRef),
+		errors.Is(err, services.ErrCustomerDetailsMismatch):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create wallet"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"walletID": wallet.ID})
+}
+
 func (h *WalletHandler) CheckWalletExists(c *fiber.Ctx) error {
 	var req struct {
 		WalletID string `json:"walletID"`
@@ -29,21 +103,29 @@ func (h *WalletHandler) CheckWalletExists(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
 	}
 
-	exists, err := h.walletService.CheckWalletExists(c.Context(), walletID)
+	wallet, err := h.walletService.GetWallet(c.UserContext(), middleware.CurrentClient(c), walletID)
+	if errors.Is(err, services.ErrWalletNotFound) {
+		return c.JSON(fiber.Map{"exists": false})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check wallet existence"})
 	}
 
-	return c.JSON(fiber.Map{"exists": exists})
+	return c.JSON(fiber.Map{"exists": true, "status": wallet.Status})
 }
 
 func (h *WalletHandler) TopUpWallet(c *fiber.Ctx) error {
 	var req struct {
-		WalletID string  `json:"walletID"`
-		Amount   float64 `json:"amount"`
+		WalletID    string       `json:"walletID"`
+		Amount      models.Money `json:"amount"`
+		ExternalRef string       `json:"externalRef"`
+		Pending     bool         `json:"pending"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
@@ -52,24 +134,62 @@ func (h *WalletHandler) TopUpWallet(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
 	}
 
-	if req.Amount <= 0 {
+	if !req.Amount.IsPositive() {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
 	}
 
-	err = h.walletService.TopUpWallet(c.Context(), walletID, req.Amount)
+	externalRef, msg := parseExternalRef(req.ExternalRef)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	if req.Pending {
+		transaction, err := h.walletService.TopUpWalletPending(c.UserContext(), middleware.CurrentClient(c), walletID, req.Amount, externalRef)
+		if err != nil {
+			return walletErrorResponse(c, err, "Failed to top up wallet")
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message":       "Top-up accepted and awaiting settlement",
+			"walletID":      transaction.WalletID,
+			"transactionID": transaction.ID,
+			"externalRef":   transaction.ExternalRef,
+			"status":        transaction.Status,
+			"amount":        transaction.Amount,
+		})
+	}
+
+	transaction, balance, err := h.walletService.TopUpWallet(c.UserContext(), middleware.CurrentClient(c), walletID, req.Amount, externalRef)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return walletErrorResponse(c, err, "Failed to top up wallet")
 	}
 
-	return c.JSON(fiber.Map{"message": "Wallet topped up successfully"})
+	return c.JSON(topUpResponse(transaction, balance))
 }
 
-func (h *WalletHandler) GetMonthlyTopUpStats(c *fiber.Ctx) error {
+// topUpResponse describes the top-up's transaction so partners can reconcile
+// it with their own records.
+func topUpResponse(transaction *models.Transaction, balance models.Money) fiber.Map {
+	return fiber.Map{
+		"message":       "Wallet topped up successfully",
+		"walletID":      transaction.WalletID,
+		"transactionID": transaction.ID,
+		"externalRef":   transaction.ExternalRef,
+		"status":        transaction.Status,
+		"amount":        transaction.Amount,
+		"balanceAfter":  balance,
+	}
+}
+
+func (h *WalletHandler) WithdrawWallet(c *fiber.Ctx) error {
 	var req struct {
-		WalletID string `json:"walletID"`
+		WalletID string       `json:"walletID"`
+		Amount   models.Money `json:"amount"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
@@ -78,15 +198,56 @@ func (h *WalletHandler) GetMonthlyTopUpStats(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
 	}
 
-	count, sum, err := h.walletService.GetMonthlyTopUpStats(c.Context(), walletID)
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+	}
+
+	err = h.walletService.WithdrawWallet(c.UserContext(), middleware.CurrentClient(c), walletID, req.Amount)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get monthly top-up stats"})
+		return walletErrorResponse(c, err, "Failed to withdraw from wallet")
 	}
 
-	return c.JSON(fiber.Map{
-		"count": count,
-		"sum":   sum,
-	})
+	return c.JSON(fiber.Map{"message": "Withdrawal completed successfully"})
+}
+
+func (h *WalletHandler) Transfer(c *fiber.Ctx) error {
+	var req struct {
+		SourceWalletID      string       `json:"sourceWalletID"`
+		DestinationWalletID string       `json:"destinationWalletID"`
+		Amount              models.Money `json:"amount"`
+		Description         string       `json:"description"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sourceID, err := uuid.Parse(req.SourceWalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid source wallet ID"})
+	}
+
+	destinationID, err := uuid.Parse(req.DestinationWalletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid destination wallet ID"})
+	}
+
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+	}
+
+	transferID, err := h.walletService.Transfer(c.UserContext(), middleware.CurrentClient(c), sourceID, destinationID, req.Amount, req.Description)
+	if errors.Is(err, services.ErrSameWallet) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to transfer funds")
+	}
+
+	return c.JSON(fiber.Map{"transferID": transferID})
 }
 
 func (h *WalletHandler) GetBalance(c *fiber.Ctx) error {
@@ -103,10 +264,129 @@ func (h *WalletHandler) GetBalance(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid wallet ID"})
 	}
 
-	balance, err := h.walletService.GetBalance(c.Context(), walletID)
+	wallet, err := h.walletService.GetWallet(c.UserContext(), middleware.CurrentClient(c), walletID)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to get wallet balance")
+	}
+
+	available, err := h.walletService.AvailableBalance(c.UserContext(), wallet)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to get wallet balance")
+	}
+
+	return c.JSON(fiber.Map{"balance": wallet.Balance, "available": available, "status": wallet.Status})
+}
+
+// phoneWalletRequest identifies a wallet by its holder's phone number.
+// Currency is needed only when the holder has several wallets.
+type phoneWalletRequest struct {
+	Phone    string `json:"phone"`
+	Currency string `json:"currency"`
+}
+
+func (h *WalletHandler) CheckWalletExistsByPhone(c *fiber.Ctx) error {
+	var req phoneWalletRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	wallet, err := h.walletService.ResolveWalletByPhone(c.UserContext(), middleware.CurrentClient(c), req.Phone, req.Currency)
+	if errors.Is(err, services.ErrWalletNotFound) {
+		return c.JSON(fiber.Map{"exists": false})
+	}
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to check wallet existence")
+	}
+
+	return c.JSON(fiber.Map{"exists": true, "walletID": wallet.ID, "status": wallet.Status})
+}
+
+func (h *WalletHandler) GetBalanceByPhone(c *fiber.Ctx) error {
+	var req phoneWalletRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	wallet, err := h.walletService.ResolveWalletByPhone(c.UserContext(), middleware.CurrentClient(c), req.Phone, req.Currency)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get wallet balance"})
+		return walletErrorResponse(c, err, "Failed to get wallet balance")
 	}
 
-	return c.JSON(fiber.Map{"balance": balance})
+	available, err := h.walletService.AvailableBalance(c.UserContext(), wallet)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to get wallet balance")
+	}
+
+	return c.JSON(fiber.Map{"walletID": wallet.ID, "balance": wallet.Balance, "available": available, "status": wallet.Status})
+}
+
+func (h *WalletHandler) TopUpWalletByPhone(c *fiber.Ctx) error {
+	var req struct {
+		phoneWalletRequest
+		Amount      models.Money `json:"amount"`
+		ExternalRef string       `json:"externalRef"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidAmount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount must be positive"})
+	}
+
+	externalRef, msg := parseExternalRef(req.ExternalRef)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	client := middleware.CurrentClient(c)
+	wallet, err := h.walletService.ResolveWalletByPhone(c.UserContext(), client, req.Phone, req.Currency)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to top up wallet")
+	}
+
+	transaction, balance, err := h.walletService.TopUpWallet(c.UserContext(), client, wallet.ID, req.Amount, externalRef)
+	if err != nil {
+		return walletErrorResponse(c, err, "Failed to top up wallet")
+	}
+
+	return c.JSON(topUpResponse(transaction, balance))
+}
+
+// walletErrorResponse maps wallet service errors to HTTP responses. Unknown
+// errors are reported as a 500 with the given message so internals don't leak.
+func walletErrorResponse(c *fiber.Ctx, err error, message string) error {
+	var limitErr *models.LimitExceededError
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Wallet not found"})
+	case errors.Is(err, models.ErrInvalidPhone),
+		errors.Is(err, models.ErrAmbiguousPhone):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTransactionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+	case errors.Is(err, services.ErrAmbiguousWallet),
+		errors.Is(err, services.ErrDuplicateTransactionRef):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.As(err, &limitErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":     limitErr.Error(),
+			"limit":     limitErr.Limit,
+			"max":       limitErr.Max,
+			"used":      limitErr.Used,
+			"remaining": limitErr.Remaining,
+		})
+	case errors.Is(err, models.ErrInsufficientFunds),
+		errors.Is(err, models.ErrBalanceLimitExceeded),
+		errors.Is(err, models.ErrCreditsNotAllowed),
+		errors.Is(err, models.ErrDebitsNotAllowed),
+		errors.Is(err, services.ErrCurrencyMismatch):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
 }
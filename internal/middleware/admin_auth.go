@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminAuthMiddleware authenticates an admin by the bearer token in the
+// Authorization header. tokens maps admin names to their tokens.
+func AdminAuthMiddleware(tokens map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing admin token",
+			})
+		}
+
+		for admin, expected := range tokens {
+			if expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+				c.Locals(constants.AdminLocalsKey, admin)
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid admin token",
+		})
+	}
+}
+
+// CurrentAdmin returns the name of the admin authenticated by AdminAuthMiddleware.
+func CurrentAdmin(c *fiber.Ctx) string {
+	admin, _ := c.Locals(constants.AdminLocalsKey).(string)
+	return admin
+}
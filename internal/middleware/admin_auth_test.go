@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", AdminAuthMiddleware(map[string]string{"ops": "t0ken"}), func(c *fiber.Ctx) error {
+		return c.SendString(CurrentAdmin(c))
+	})
+
+	tests := []struct {
+		header string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{"t0ken", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer t0ken", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(fiber.HeaderAuthorization, tt.header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("Authorization %q: got status %d; want %d", tt.header, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != "ops" {
+				t.Errorf("expected the admin name ops, got %q", body)
+			}
+		}
+	}
+}
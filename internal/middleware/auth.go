@@ -1,13 +1,25 @@
 package middleware
 
 import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/services"
 	"github.com/mabduqayum/ewallet/internal/utils/hmac"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthMiddleware(clientService *services.ClientService) fiber.Handler {
+// AuthMiddleware authenticates the client by X-UserId and X-Digest. Clients
+// on HMAC-SHA1 sign the body; clients on HMAC-SHA256 sign the canonical
+// request string and must send X-Timestamp and X-Nonce, which protect against
+// replay. A digest made with any of the client's valid credentials is
+// accepted, so secrets can be rotated with overlapping validity.
+func AuthMiddleware(clientService *services.ClientService, nonceService *services.NonceService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Get("X-UserId")
 		digest := c.Get("X-Digest")
@@ -18,22 +30,97 @@ func AuthMiddleware(clientService *services.ClientService) fiber.Handler {
 			})
 		}
 
-		client, err := clientService.GetClientByAPIKey(c.Context(), userID)
-		if err != nil {
+		client, clientSecrets, err := clientService.AuthenticateClient(c.UserContext(), userID)
+		if errors.Is(err, services.ErrClientNotFound) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid user ID",
 			})
 		}
+		if err != nil {
+			// The API key is a credential and is not logged.
+			log.Printf("Failed to load client for authentication: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to authenticate client",
+			})
+		}
 
 		body := c.Body()
-		if !hmac.ValidateHMAC(string(body), client.SecretKey, digest) {
+		if client.SignatureScheme == models.SignatureSchemeHMACSHA256 {
+			if status, message := verifySHA256Signature(c, nonceService, client, clientSecrets, digest, body); status != 0 {
+				return c.Status(status).JSON(fiber.Map{
+					"error": message,
+				})
+			}
+		} else if !anySecret(clientSecrets, func(secret string) bool {
+			return hmac.ValidateHMAC(string(body), secret, digest)
+		}) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid digest",
 			})
 		}
 
+		// Only a client that proved its identity learns that it is deactivated.
+		if !client.Active {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Client is deactivated",
+			})
+		}
+
 		c.Request().SetBody(body)
+		c.Locals(constants.ClientLocalsKey, client)
 
 		return c.Next()
 	}
 }
+
+// verifySHA256Signature returns the status and error message to reject the
+// request with, or a zero status if it is valid. The nonce is only recorded
+// once the signature is valid, so forged requests can't burn a partner's nonces.
+func verifySHA256Signature(c *fiber.Ctx, nonceService *services.NonceService, client *models.Client, clientSecrets []models.Secret, digest string, body []byte) (int, string) {
+	timestamp := c.Get(constants.TimestampHeader)
+	nonce := c.Get(constants.NonceHeader)
+	if timestamp == "" || nonce == "" {
+		return fiber.StatusUnauthorized, "Missing " + constants.TimestampHeader + " or " + constants.NonceHeader + " header"
+	}
+	if len(nonce) > constants.MaxNonceLength {
+		return fiber.StatusUnauthorized, "Nonce is too long"
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fiber.StatusUnauthorized, "Invalid timestamp"
+	}
+	if err := nonceService.CheckTimestamp(time.Unix(seconds, 0)); err != nil {
+		return fiber.StatusUnauthorized, "Request timestamp is outside the allowed window"
+	}
+
+	message := hmac.CanonicalString(c.Method(), c.OriginalURL(), timestamp, nonce, body)
+	if !anySecret(clientSecrets, func(secret string) bool {
+		return hmac.ValidateHMACSHA256(message, secret, digest)
+	}) {
+		return fiber.StatusUnauthorized, "Invalid digest"
+	}
+
+	if err := nonceService.Use(c.UserContext(), client.ID, nonce); err != nil {
+		if errors.Is(err, services.ErrNonceReused) {
+			return fiber.StatusUnauthorized, "Nonce was already used"
+		}
+		return fiber.StatusInternalServerError, "Failed to verify nonce"
+	}
+	return 0, ""
+}
+
+func anySecret(clientSecrets []models.Secret, valid func(secret string) bool) bool {
+	for _, secret := range clientSecrets {
+		if valid(string(secret)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentClient returns the client authenticated by AuthMiddleware.
+func CurrentClient(c *fiber.Ctx) *models.Client {
+	client, _ := c.Locals(constants.ClientLocalsKey).(*models.Client)
+	return client
+}
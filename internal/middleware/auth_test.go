@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/hmac"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type memoryClientRepository struct {
+	repository.ClientRepository
+	clients map[string]*models.Client
+}
+
+func (r *memoryClientRepository) GetByAPIKey(_ context.Context, apiKey string) (*models.Client, error) {
+	if client, ok := r.clients[apiKey]; ok {
+		return client, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+type memoryCredentialRepository struct {
+	repository.ClientCredentialRepository
+	credentials map[uuid.UUID][]*models.ClientCredential
+}
+
+func (r *memoryCredentialRepository) GetValid(_ context.Context, clientID uuid.UUID, at time.Time) ([]*models.ClientCredential, error) {
+	var valid []*models.ClientCredential
+	for _, credential := range r.credentials[clientID] {
+		if credential.ValidAt(at) {
+			valid = append(valid, credential)
+		}
+	}
+	return valid, nil
+}
+
+type memoryNonceRepository struct {
+	mu     sync.Mutex
+	nonces map[string]bool
+}
+
+func (r *memoryNonceRepository) Use(_ context.Context, clientID uuid.UUID, nonce string, _, _ time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := clientID.String() + "/" + nonce
+	if r.nonces[key] {
+		return false, nil
+	}
+	r.nonces[key] = true
+	return true, nil
+}
+
+func (r *memoryNonceRepository) DeleteExpired(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// newAuthTestApp serves /balance behind AuthMiddleware for the given clients
+// and returns the plaintext secret of each.
+func newAuthTestApp(t *testing.T, clients ...*models.Client) (*fiber.App, map[uuid.UUID]string) {
+	t.Helper()
+	keyring, err := secrets.NewKeyring("test", map[string]string{
+		"test": base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, secrets.KeySize)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	clientRepo := &memoryClientRepository{clients: map[string]*models.Client{}}
+	credentialRepo := &memoryCredentialRepository{credentials: map[uuid.UUID][]*models.ClientCredential{}}
+	clientSecrets := map[uuid.UUID]string{}
+	for _, client := range clients {
+		credential := models.NewClientCredential(client.ID, time.Now().Add(-time.Minute), nil)
+		ciphertext, keyID, err := keyring.Encrypt(string(credential.Secret), client.ID[:])
+		if err != nil {
+			t.Fatalf("failed to encrypt secret: %v", err)
+		}
+		credential.EncryptedSecret, credential.SecretKeyID = ciphertext, &keyID
+
+		clientRepo.clients[client.ApiKey] = client
+		credentialRepo.credentials[client.ID] = []*models.ClientCredential{credential}
+		clientSecrets[client.ID] = string(credential.Secret)
+	}
+
+	clientService := services.NewClientService(nil, clientRepo, nil, credentialRepo, keyring, time.Hour)
+	nonceService := services.NewNonceService(&memoryNonceRepository{nonces: map[string]bool{}}, 5*time.Minute)
+
+	app := fiber.New()
+	app.Post("/balance", AuthMiddleware(clientService, nonceService), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"client": CurrentClient(c).Name})
+	})
+	return app, clientSecrets
+}
+
+func sendSigned(t *testing.T, app *fiber.App, headers map[string]string, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/balance", strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(respBody)
+}
+
+func TestAuthMiddlewareHMACSHA256(t *testing.T) {
+	client := models.NewClient("partner")
+	client.SignatureScheme = models.SignatureSchemeHMACSHA256
+	app, clientSecrets := newAuthTestApp(t, client)
+	body := `{"walletID":"42"}`
+
+	signed := func(timestamp time.Time, nonce string) map[string]string {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		message := hmac.CanonicalString("POST", "/balance", ts, nonce, []byte(body))
+		return map[string]string{
+			"X-UserId":                client.ApiKey,
+			"X-Digest":                hmac.CalculateHMACSHA256(message, clientSecrets[client.ID]),
+			constants.TimestampHeader: ts,
+			constants.NonceHeader:     nonce,
+		}
+	}
+	without := func(headers map[string]string, name string) map[string]string {
+		delete(headers, name)
+		return headers
+	}
+	with := func(headers map[string]string, name, value string) map[string]string {
+		headers[name] = value
+		return headers
+	}
+
+	rejected := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"missing timestamp", without(signed(time.Now(), "n-1"), constants.TimestampHeader), "Missing X-Timestamp or X-Nonce header"},
+		{"missing nonce", without(signed(time.Now(), "n-1"), constants.NonceHeader), "Missing X-Timestamp or X-Nonce header"},
+		{"malformed timestamp", with(signed(time.Now(), "n-1"), constants.TimestampHeader, "yesterday"), "Invalid timestamp"},
+		{"stale timestamp", signed(time.Now().Add(-10*time.Minute), "n-1"), "Request timestamp is outside the allowed window"},
+		{"future timestamp", signed(time.Now().Add(10*time.Minute), "n-1"), "Request timestamp is outside the allowed window"},
+		{"nonce too long", signed(time.Now(), strings.Repeat("n", constants.MaxNonceLength+1)), "Nonce is too long"},
+		// The digest covers the timestamp, so it can't be moved into the window.
+		{"re-timed request", with(signed(time.Now().Add(-10*time.Minute), "n-1"), constants.TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10)), "Invalid digest"},
+		{"SHA1 digest", with(signed(time.Now(), "n-1"), "X-Digest", hmac.CalculateHMAC(body, clientSecrets[client.ID])), "Invalid digest"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := sendSigned(t, app, tt.headers, body)
+			if status != http.StatusUnauthorized || !strings.Contains(resp, tt.want) {
+				t.Errorf("got %d %s; want 401 %q", status, resp, tt.want)
+			}
+		})
+	}
+
+	// None of the rejected requests used up n-1.
+	if status, resp := sendSigned(t, app, signed(time.Now(), "n-1"), body); status != http.StatusOK {
+		t.Fatalf("expected a valid request to pass; got %d %s", status, resp)
+	}
+	if status, resp := sendSigned(t, app, signed(time.Now(), "n-1"), body); status != http.StatusUnauthorized || !strings.Contains(resp, "Nonce was already used") {
+		t.Errorf("expected a replayed nonce to be rejected; got %d %s", status, resp)
+	}
+
+	forged := with(signed(time.Now(), "n-2"), "X-Digest", strings.Repeat("0", 64))
+	if status, _ := sendSigned(t, app, forged, body); status != http.StatusUnauthorized {
+		t.Fatalf("expected a forged digest to be rejected; got %d", status)
+	}
+	if status, resp := sendSigned(t, app, signed(time.Now(), "n-2"), body); status != http.StatusOK {
+		t.Errorf("a forged request must not use up the nonce; got %d %s", status, resp)
+	}
+}
+
+func TestAuthMiddlewareHMACSHA1(t *testing.T) {
+	client := models.NewClient("legacy partner")
+	app, clientSecrets := newAuthTestApp(t, client)
+	body := `{"walletID":"42"}`
+
+	headers := map[string]string{"X-UserId": client.ApiKey, "X-Digest": hmac.CalculateHMAC(body, clientSecrets[client.ID])}
+	// SHA1 clients send neither a timestamp nor a nonce, and may repeat a request.
+	for i := 0; i < 2; i++ {
+		if status, resp := sendSigned(t, app, headers, body); status != http.StatusOK || resp != `{"client":"legacy partner"}` {
+			t.Fatalf("request %d: got %d %s", i, status, resp)
+		}
+	}
+
+	headers["X-Digest"] = hmac.CalculateHMAC(`{"walletID":"43"}`, clientSecrets[client.ID])
+	if status, _ := sendSigned(t, app, headers, body); status != http.StatusUnauthorized {
+		t.Errorf("expected a digest of another body to be rejected; got %d", status)
+	}
+
+	if status, _ := sendSigned(t, app, map[string]string{"X-UserId": "unknown", "X-Digest": "00"}, body); status != http.StatusUnauthorized {
+		t.Errorf("expected an unknown client to be rejected; got %d", status)
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdempotencyMiddleware makes a mutating endpoint safe to retry. When the
+// request carries an Idempotency-Key header, the first response is stored per
+// client and returned as-is for every retry with the same key and body.
+// It must run after AuthMiddleware.
+func IdempotencyMiddleware(idempotencyService *services.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(constants.IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > constants.MaxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency key is too long",
+			})
+		}
+
+		client := CurrentClient(c)
+		if client == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing authenticated client",
+			})
+		}
+
+		hash := requestHash(c)
+		stored, err := idempotencyService.Begin(c.UserContext(), client.ID, key, hash)
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused), errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check idempotency key",
+			})
+		case stored != nil:
+			c.Set(constants.IdempotentReplayedHeader, "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(*stored.StatusCode).Send(stored.ResponseBody)
+		}
+
+		// The handler's changes and the stored response are committed
+		// together, so a retry never runs a request whose changes were kept.
+		var handlerErr error
+		err = idempotencyService.Process(c.UserContext(), client.ID, key, hash, func(ctx context.Context) (int, []byte, error) {
+			c.SetUserContext(ctx)
+			handlerErr = c.Next()
+			return c.Response().StatusCode(), c.Response().Body(), handlerErr
+		})
+		switch {
+		case handlerErr != nil:
+			return handlerErr
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			// The handler's changes were rolled back; its response must not reach the client.
+			log.Printf("Failed to complete request with idempotency key %q: %v", key, err)
+			c.Response().ResetBody()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to complete request",
+			})
+		}
+		return nil
+	}
+}
+
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{' '})
+	h.Write([]byte(c.Path()))
+	h.Write([]byte{'\n'})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// inlineTransactor runs fn without a transaction.
+type inlineTransactor struct{}
+
+func (inlineTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type memoryIdempotencyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*models.IdempotencyKey
+	// saveErr, if set, makes SaveResponse fail.
+	saveErr error
+}
+
+func (r *memoryIdempotencyRepository) Reserve(_ context.Context, key *models.IdempotencyKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.keys[key.Key]; ok && existing.ExpiresAt.After(key.CreatedAt) {
+		return false, nil
+	}
+	r.keys[key.Key] = key
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepository) Get(_ context.Context, _ uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k, ok := r.keys[key]; ok {
+		return k, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *memoryIdempotencyRepository) Lock(_ context.Context, _ uuid.UUID, key, requestHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[key]
+	return ok && k.RequestHash == requestHash && !k.Completed(), nil
+}
+
+func (r *memoryIdempotencyRepository) SaveResponse(_ context.Context, _ uuid.UUID, key string, statusCode int, body []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.keys[key].StatusCode = &statusCode
+	r.keys[key].ResponseBody = append([]byte(nil), body...)
+	r.keys[key].ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Delete(_ context.Context, _ uuid.UUID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, key)
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpired(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	repo := &memoryIdempotencyRepository{keys: map[string]*models.IdempotencyKey{}}
+	client := models.NewClient("test")
+	calls := 0
+
+	app := fiber.New()
+	app.Post("/top-up",
+		func(c *fiber.Ctx) error {
+			c.Locals(constants.ClientLocalsKey, client)
+			return c.Next()
+		},
+		IdempotencyMiddleware(services.NewIdempotencyService(inlineTransactor{}, repo, time.Hour, time.Minute)),
+		func(c *fiber.Ctx) error {
+			calls++
+			return c.JSON(fiber.Map{"call": calls})
+		})
+
+	send := func(key, body string) (int, string) {
+		req, _ := http.NewRequest("POST", "/top-up", strings.NewReader(body))
+		req.Header.Set(constants.IdempotencyKeyHeader, key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(respBody)
+	}
+
+	status, first := send("key-1", `{"amount":"10"}`)
+	if status != http.StatusOK || first != `{"call":1}` {
+		t.Fatalf("unexpected first response: %d %s", status, first)
+	}
+
+	status, replay := send("key-1", `{"amount":"10"}`)
+	if status != http.StatusOK || replay != first {
+		t.Errorf("expected replay of %s; got %d %s", first, status, replay)
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once; ran %d times", calls)
+	}
+
+	if status, _ := send("key-1", `{"amount":"20"}`); status != http.StatusConflict {
+		t.Errorf("expected 409 for a different body; got %d", status)
+	}
+
+	if status, body := send("key-2", `{"amount":"10"}`); status != http.StatusOK || body != `{"call":2}` {
+		t.Errorf("expected a new key to run the handler; got %d %s", status, body)
+	}
+}
+
+func TestIdempotencyKeyOfUnfinishedRequestIsReleasedAfterLockTTL(t *testing.T) {
+	repo := &memoryIdempotencyRepository{keys: map[string]*models.IdempotencyKey{}}
+	service := services.NewIdempotencyService(inlineTransactor{}, repo, time.Hour, 10*time.Millisecond)
+	ctx := context.Background()
+	clientID := uuid.New()
+
+	// The first request reserves the key and never completes.
+	if stored, err := service.Begin(ctx, clientID, "key-1", "hash"); err != nil || stored != nil {
+		t.Fatalf("expected the key to be reserved; got %v, %v", stored, err)
+	}
+	if _, err := service.Begin(ctx, clientID, "key-1", "hash"); !errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("expected ErrIdempotencyKeyInProgress, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if stored, err := service.Begin(ctx, clientID, "key-1", "hash"); err != nil || stored != nil {
+		t.Fatalf("expected the key to be reserved again after the lock TTL; got %v, %v", stored, err)
+	}
+	if err := service.Process(ctx, clientID, "key-1", "hash", func(context.Context) (int, []byte, error) {
+		return 200, []byte("{}"), nil
+	}); err != nil {
+		t.Fatalf("failed to process: %v", err)
+	}
+	if repo.keys["key-1"].ExpiresAt.Before(time.Now().Add(30 * time.Minute)) {
+		t.Errorf("expected a completed key to be kept for the full TTL")
+	}
+}
+
+func TestIdempotencyMiddlewareFailsRequestWhoseResponseIsNotStored(t *testing.T) {
+	repo := &memoryIdempotencyRepository{keys: map[string]*models.IdempotencyKey{}}
+	client := models.NewClient("test")
+	calls := 0
+	status := http.StatusOK
+
+	app := fiber.New()
+	app.Post("/top-up",
+		func(c *fiber.Ctx) error {
+			c.Locals(constants.ClientLocalsKey, client)
+			return c.Next()
+		},
+		IdempotencyMiddleware(services.NewIdempotencyService(inlineTransactor{}, repo, time.Hour, time.Minute)),
+		func(c *fiber.Ctx) error {
+			calls++
+			return c.Status(status).JSON(fiber.Map{"call": calls})
+		})
+
+	send := func() (int, string) {
+		req, _ := http.NewRequest("POST", "/top-up", strings.NewReader(`{"amount":"10"}`))
+		req.Header.Set(constants.IdempotencyKeyHeader, "key-1")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(respBody)
+	}
+
+	// The handler's changes are rolled back with the response, so the client
+	// must see a failure and be able to retry.
+	repo.saveErr = errors.New("connection lost")
+	if code, body := send(); code != http.StatusInternalServerError || strings.Contains(body, "call") {
+		t.Fatalf("expected 500 without the handler's response; got %d %s", code, body)
+	}
+
+	repo.saveErr = nil
+	status = http.StatusServiceUnavailable
+	if code, _ := send(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the handler's 503; got %d", code)
+	}
+
+	status = http.StatusOK
+	if code, body := send(); code != http.StatusOK || body != `{"call":3}` {
+		t.Fatalf("expected the released key to run the handler again; got %d %s", code, body)
+	}
+	if code, body := send(); code != http.StatusOK || body != `{"call":3}` {
+		t.Errorf("expected a replay of the stored response; got %d %s", code, body)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScope rejects clients without the scope. It must run after
+// AuthMiddleware.
+func RequireScope(scope models.Scope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client := CurrentClient(c)
+		if client == nil || !client.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Missing scope " + string(scope),
+				"scope": scope,
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/constants"
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequireScope(t *testing.T) {
+	client := models.NewClient("partner")
+	client.Scopes = []models.Scope{models.ScopeWalletRead}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(constants.ClientLocalsKey, client)
+		return c.Next()
+	})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Post("/balance", RequireScope(models.ScopeWalletRead), ok)
+	app.Post("/top-up", RequireScope(models.ScopeWalletTopUp), ok)
+
+	resp, err := app.Test(httptestRequest("/balance"))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for a granted scope, got %v %v", resp.StatusCode, err)
+	}
+
+	resp, err = app.Test(httptestRequest("/top-up"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), `"scope":"wallet:topup"`) {
+		t.Errorf("expected 403 naming wallet:topup, got %d %s", resp.StatusCode, body)
+	}
+}
+
+func httptestRequest(path string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+	return req
+}
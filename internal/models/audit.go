@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditClientCreated           AuditAction = "client.created"
+	AuditClientRenamed           AuditAction = "client.renamed"
+	AuditClientScopesChanged     AuditAction = "client.scopes_changed"
+	AuditClientSchemeChanged     AuditAction = "client.signature_scheme_changed"
+	AuditClientActivated         AuditAction = "client.activated"
+	AuditClientDeactivated       AuditAction = "client.deactivated"
+	AuditClientCredentialIssued  AuditAction = "client.credential_issued"
+	AuditClientCredentialRevoked AuditAction = "client.credential_revoked"
+	AuditWalletOwnerAssigned     AuditAction = "wallet.owner_assigned"
+	AuditWalletAccessGranted     AuditAction = "wallet.access_granted"
+	AuditWalletAccessRevoked     AuditAction = "wallet.access_revoked"
+	AuditWalletStatusChanged     AuditAction = "wallet.status_changed"
+	AuditIdentificationSubmitted AuditAction = "identification.submitted"
+	AuditIdentificationApproved  AuditAction = "identification.approved"
+	AuditIdentificationRejected  AuditAction = "identification.rejected"
+	AuditIdentificationExpired   AuditAction = "identification.expired"
+	AuditTransactionReversed     AuditAction = "transaction.reversed"
+	AuditTransactionCompleted    AuditAction = "transaction.completed"
+	AuditTransactionFailed       AuditAction = "transaction.failed"
+)
+
+const (
+	AuditTargetClient      = "client"
+	AuditTargetWallet      = "wallet"
+	AuditTargetTransaction = "transaction"
+)
+
+// AuditEntry records an administrative action. Details must never contain
+// secrets.
+type AuditEntry struct {
+	ID         uuid.UUID      `json:"id"`
+	Actor      string         `json:"actor"`
+	Action     AuditAction    `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetID   uuid.UUID      `json:"target_id"`
+	Details    map[string]any `json:"details,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func NewAuditEntry(actor string, action AuditAction, targetType string, targetID uuid.UUID, details map[string]any) *AuditEntry {
+	return &AuditEntry{
+		ID:         uuid.New(),
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+}
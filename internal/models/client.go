@@ -6,24 +6,65 @@ import (
 	"github.com/google/uuid"
 )
 
+// SignatureScheme is how a client signs its requests.
+type SignatureScheme string
+
+const (
+	// SignatureSchemeHMACSHA1 signs the raw body only. It is kept for partners
+	// that haven't moved to HMAC-SHA256 yet.
+	SignatureSchemeHMACSHA1 SignatureScheme = "HMAC-SHA1"
+	// SignatureSchemeHMACSHA256 signs a canonical string of method, path,
+	// timestamp, nonce and body hash, and rejects replayed requests.
+	SignatureSchemeHMACSHA256 SignatureScheme = "HMAC-SHA256"
+)
+
+func (s SignatureScheme) Valid() bool {
+	return s == SignatureSchemeHMACSHA1 || s == SignatureSchemeHMACSHA256
+}
+
+// Secret is a value that must not be logged. It prints as a placeholder.
+type Secret string
+
+func (s Secret) String() string {
+	return "[REDACTED]"
+}
+
+func (s Secret) GoString() string {
+	return `"[REDACTED]"`
+}
+
+// Client is a partner calling the API. Its signing secrets are kept as
+// ClientCredentials so they can be rotated without downtime.
 type Client struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	ApiKey    string    `json:"api_key"`
-	SecretKey string    `json:"secret_key"`
-	Active    bool      `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	ApiKey string    `json:"-"`
+	Active bool      `json:"active"`
+	// SignatureScheme defaults to HMAC-SHA1; clients opt in to HMAC-SHA256.
+	SignatureScheme SignatureScheme `json:"signature_scheme"`
+	Scopes          []Scope         `json:"scopes"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
 func NewClient(name string) *Client {
 	return &Client{
-		ID:        uuid.New(),
-		Name:      name,
-		ApiKey:    uuid.New().String(),
-		SecretKey: uuid.New().String(),
-		Active:    true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:              uuid.New(),
+		Name:            name,
+		ApiKey:          uuid.New().String(),
+		Active:          true,
+		SignatureScheme: SignatureSchemeHMACSHA1,
+		Scopes:          append([]Scope(nil), AllScopes...),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+func (c *Client) HasScope(scope Scope) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientCredential is one signing secret of a client. A client may have
+// several credentials valid at once so that a new secret can be rolled out
+// while the old one still works.
+type ClientCredential struct {
+	ID       uuid.UUID `json:"id"`
+	ClientID uuid.UUID `json:"client_id"`
+	// Secret is the plaintext secret. It is only set on a newly issued
+	// credential and on credentials loaded for authentication.
+	Secret Secret `json:"-"`
+	// EncryptedSecret is the stored form of Secret, encrypted with the master
+	// key SecretKeyID. A nil SecretKeyID marks a legacy plaintext secret.
+	EncryptedSecret string     `json:"-"`
+	SecretKeyID     *string    `json:"-"`
+	NotBefore       time.Time  `json:"not_before"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func NewClientCredential(clientID uuid.UUID, notBefore time.Time, expiresAt *time.Time) *ClientCredential {
+	return &ClientCredential{
+		ID:        uuid.New(),
+		ClientID:  clientID,
+		Secret:    Secret(uuid.New().String()),
+		NotBefore: notBefore,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ValidAt reports whether the credential may be used to sign a request at t.
+func (c *ClientCredential) ValidAt(t time.Time) bool {
+	if c.RevokedAt != nil || t.Before(c.NotBefore) {
+		return false
+	}
+	return c.ExpiresAt == nil || t.Before(*c.ExpiresAt)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClientCredentialValidAt(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	credential := NewClientCredential(uuid.New(), now, &expiresAt)
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{now.Add(-time.Second), false},
+		{now, true},
+		{expiresAt.Add(-time.Second), true},
+		{expiresAt, false},
+	}
+	for _, tt := range tests {
+		if got := credential.ValidAt(tt.at); got != tt.want {
+			t.Errorf("ValidAt(%s): got %v; want %v", tt.at.Sub(now), got, tt.want)
+		}
+	}
+
+	revokedAt := now
+	credential.RevokedAt = &revokedAt
+	if credential.ValidAt(now.Add(time.Minute)) {
+		t.Error("a revoked credential must not be valid")
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientSuspension records why and by whom a client was deactivated. A
+// suspension is in effect until it is lifted; ReactivateAt optionally
+// schedules that to happen automatically.
+type ClientSuspension struct {
+	ID           uuid.UUID  `json:"id"`
+	ClientID     uuid.UUID  `json:"client_id"`
+	Reason       string     `json:"reason"`
+	SuspendedAt  time.Time  `json:"suspended_at"`
+	SuspendedBy  string     `json:"suspended_by"`
+	ReactivateAt *time.Time `json:"reactivate_at,omitempty"`
+	LiftedAt     *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy     *string    `json:"lifted_by,omitempty"`
+}
+
+func NewClientSuspension(clientID uuid.UUID, reason, suspendedBy string, reactivateAt *time.Time) *ClientSuspension {
+	return &ClientSuspension{
+		ID:           uuid.New(),
+		ClientID:     clientID,
+		Reason:       reason,
+		SuspendedAt:  time.Now(),
+		SuspendedBy:  suspendedBy,
+		ReactivateAt: reactivateAt,
+	}
+}
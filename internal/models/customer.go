@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IdentificationStatus string
+
+const (
+	IdentificationStatusUnidentified IdentificationStatus = "UNIDENTIFIED"
+	IdentificationStatusIdentified   IdentificationStatus = "IDENTIFIED"
+)
+
+// Customer is the person holding one or more wallets, known by their phone
+// number in E.164 format. Phone numbers are unique across customers.
+type Customer struct {
+	ID                   uuid.UUID            `json:"id"`
+	Phone                string               `json:"phone"`
+	Name                 *string              `json:"name,omitempty"`
+	DateOfBirth          *time.Time           `json:"date_of_birth,omitempty"`
+	IdentificationStatus IdentificationStatus `json:"identification_status"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+}
+
+func NewCustomer(phone string, name *string, dateOfBirth *time.Time) *Customer {
+	now := time.Now()
+	return &Customer{
+		ID:                   uuid.New(),
+		Phone:                phone,
+		Name:                 name,
+		DateOfBirth:          dateOfBirth,
+		IdentificationStatus: IdentificationStatusUnidentified,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+}
+
+// CustomerDetails describe the holder of a new wallet. Phone is required; the
+// rest is optional.
+type CustomerDetails struct {
+	Phone       string
+	Name        *string
+	DateOfBirth *time.Time
+}
+
+// MatchesDetails reports whether the details agree with what is known about
+// the customer. Names are compared ignoring case and surrounding spaces.
+// Details that either side lacks always agree.
+func (c *Customer) MatchesDetails(details CustomerDetails) bool {
+	if c.Name != nil && details.Name != nil &&
+		!strings.EqualFold(strings.TrimSpace(*c.Name), strings.TrimSpace(*details.Name)) {
+		return false
+	}
+	if c.DateOfBirth != nil && details.DateOfBirth != nil &&
+		c.DateOfBirth.Format(time.DateOnly) != details.DateOfBirth.Format(time.DateOnly) {
+		return false
+	}
+	return true
+}
+
+// LacksDetails reports whether the details include something not yet known
+// about the customer.
+func (c *Customer) LacksDetails(details CustomerDetails) bool {
+	return (c.Name == nil && details.Name != nil) || (c.DateOfBirth == nil && details.DateOfBirth != nil)
+}
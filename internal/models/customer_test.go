@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCustomerDetails(t *testing.T) {
+	name := "Ali Valiev"
+	dateOfBirth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	customer := NewCustomer("+992901234567", &name, nil)
+
+	sameName := " ali valiev "
+	otherName := "Vali Aliev"
+	tests := []struct {
+		details        CustomerDetails
+		matches, lacks bool
+	}{
+		{CustomerDetails{}, true, false},
+		{CustomerDetails{Name: &sameName}, true, false},
+		{CustomerDetails{Name: &otherName}, false, false},
+		{CustomerDetails{DateOfBirth: &dateOfBirth}, true, true},
+	}
+	for i, tt := range tests {
+		if got := customer.MatchesDetails(tt.details); got != tt.matches {
+			t.Errorf("case %d: MatchesDetails = %t; want %t", i, got, tt.matches)
+		}
+		if got := customer.LacksDetails(tt.details); got != tt.lacks {
+			t.Errorf("case %d: LacksDetails = %t; want %t", i, got, tt.lacks)
+		}
+	}
+
+	customer.DateOfBirth = &dateOfBirth
+	otherDate := dateOfBirth.AddDate(0, 0, 1)
+	if customer.MatchesDetails(CustomerDetails{DateOfBirth: &otherDate}) {
+		t.Error("expected a different date of birth not to match")
+	}
+}
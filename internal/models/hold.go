@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HoldStatus string
+
+// A hold starts out ACTIVE and ends CAPTURED, RELEASED or, once its expiry
+// passes without either, EXPIRED.
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusReleased HoldStatus = "RELEASED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+var (
+	ErrHoldNotActive      = errors.New("hold is no longer active")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+)
+
+// Hold reserves part of a wallet's balance for the client that placed it.
+// While active it reduces the available balance but not the balance itself;
+// capturing it debits the wallet.
+type Hold struct {
+	ID       uuid.UUID `json:"id"`
+	WalletID uuid.UUID `json:"wallet_id"`
+	// ClientID is the partner that placed the hold and the only one that can
+	// capture or release it.
+	ClientID       uuid.UUID  `json:"client_id"`
+	Amount         Money      `json:"amount"`
+	CapturedAmount Money      `json:"captured_amount"`
+	Description    string     `json:"description"`
+	Status         HoldStatus `json:"status"`
+	// CaptureTransactionID is the HOLD_CAPTURE transaction of a captured hold.
+	CaptureTransactionID *uuid.UUID `json:"capture_transaction_id,omitempty"`
+	ExpiresAt            time.Time  `json:"expires_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+func NewHold(walletID, clientID uuid.UUID, amount Money, description string, ttl time.Duration) *Hold {
+	now := time.Now()
+	return &Hold{
+		ID:          uuid.New(),
+		WalletID:    walletID,
+		ClientID:    clientID,
+		Amount:      amount,
+		Description: description,
+		Status:      HoldStatusActive,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// ActiveAt reports whether the hold still reserves money at now. Holds past
+// their expiry stop counting before the expiry job marks them EXPIRED.
+func (h *Hold) ActiveAt(now time.Time) bool {
+	return h.Status == HoldStatusActive && now.Before(h.ExpiresAt)
+}
+
+// Capture settles amount of the hold, which may be less than the held amount;
+// the rest is released.
+func (h *Hold) Capture(amount Money, now time.Time) error {
+	if !h.ActiveAt(now) {
+		return ErrHoldNotActive
+	}
+	if amount > h.Amount {
+		return ErrCaptureExceedsHold
+	}
+	h.CapturedAmount = amount
+	h.Status = HoldStatusCaptured
+	h.UpdatedAt = now
+	return nil
+}
+
+// Release gives the held amount back to the available balance.
+func (h *Hold) Release(now time.Time) error {
+	if !h.ActiveAt(now) {
+		return ErrHoldNotActive
+	}
+	h.Status = HoldStatusReleased
+	h.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHoldCapture(t *testing.T) {
+	now := time.Now()
+	hold := NewHold(uuid.New(), uuid.New(), NewMoney(10), "Order 1", time.Hour)
+
+	if err := hold.Capture(NewMoney(11), now); !errors.Is(err, ErrCaptureExceedsHold) {
+		t.Fatalf("expected ErrCaptureExceedsHold, got %v", err)
+	}
+	if err := hold.Capture(NewMoney(4), now); err != nil {
+		t.Fatalf("partial capture failed: %v", err)
+	}
+	if hold.Status != HoldStatusCaptured || hold.CapturedAmount != NewMoney(4) {
+		t.Fatalf("unexpected hold after capture: %+v", hold)
+	}
+	if err := hold.Release(now); !errors.Is(err, ErrHoldNotActive) {
+		t.Fatalf("expected ErrHoldNotActive releasing a captured hold, got %v", err)
+	}
+}
+
+func TestHoldExpiry(t *testing.T) {
+	hold := NewHold(uuid.New(), uuid.New(), NewMoney(10), "", time.Minute)
+
+	if !hold.ActiveAt(hold.CreatedAt) {
+		t.Fatal("new hold must be active")
+	}
+	later := hold.ExpiresAt
+	if hold.ActiveAt(later) {
+		t.Fatal("hold must stop being active at its expiry")
+	}
+	if err := hold.Capture(NewMoney(10), later); !errors.Is(err, ErrHoldNotActive) {
+		t.Fatalf("expected ErrHoldNotActive capturing an expired hold, got %v", err)
+	}
+}
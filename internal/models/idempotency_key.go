@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey remembers the response to a mutating request so that a retry
+// with the same key can be answered without executing the request again.
+// StatusCode is nil while the original request is still being processed; until
+// then the key expires after a short lock TTL rather than the full TTL.
+type IdempotencyKey struct {
+	ClientID     uuid.UUID `json:"client_id"`
+	Key          string    `json:"key"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   *int      `json:"status_code"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func NewIdempotencyKey(clientID uuid.UUID, key, requestHash string, ttl time.Duration) *IdempotencyKey {
+	now := time.Now()
+	return &IdempotencyKey{
+		ClientID:    clientID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != nil
+}
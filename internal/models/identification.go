@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DocumentType string
+
+const (
+	DocumentTypePassport       DocumentType = "PASSPORT"
+	DocumentTypeNationalID     DocumentType = "NATIONAL_ID"
+	DocumentTypeDriversLicense DocumentType = "DRIVERS_LICENSE"
+)
+
+func (t DocumentType) Valid() bool {
+	switch t {
+	case DocumentTypePassport, DocumentTypeNationalID, DocumentTypeDriversLicense:
+		return true
+	}
+	return false
+}
+
+type IdentificationState string
+
+// An identification starts out PENDING and is reviewed to APPROVED or
+// REJECTED. An APPROVED identification becomes EXPIRED when its document
+// expires, or SUPERSEDED when a newer one of the same wallet is approved.
+const (
+	IdentificationPending    IdentificationState = "PENDING"
+	IdentificationApproved   IdentificationState = "APPROVED"
+	IdentificationRejected   IdentificationState = "REJECTED"
+	IdentificationExpired    IdentificationState = "EXPIRED"
+	IdentificationSuperseded IdentificationState = "SUPERSEDED"
+)
+
+func (s IdentificationState) Valid() bool {
+	switch s {
+	case IdentificationPending, IdentificationApproved, IdentificationRejected, IdentificationExpired, IdentificationSuperseded:
+		return true
+	}
+	return false
+}
+
+// Identification is the identity document submitted for a wallet's holder.
+// Approving it makes the wallet IDENTIFIED.
+type Identification struct {
+	ID                uuid.UUID           `json:"id"`
+	WalletID          uuid.UUID           `json:"wallet_id"`
+	DocumentType      DocumentType        `json:"document_type"`
+	DocumentNumber    string              `json:"document_number"`
+	DocumentExpiresAt time.Time           `json:"document_expires_at"`
+	Status            IdentificationState `json:"status"`
+	RejectionReason   *string             `json:"rejection_reason,omitempty"`
+	SubmittedBy       string              `json:"submitted_by"`
+	ReviewedBy        *string             `json:"reviewed_by,omitempty"`
+	ReviewedAt        *time.Time          `json:"reviewed_at,omitempty"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+}
+
+func NewIdentification(walletID uuid.UUID, documentType DocumentType, documentNumber string, documentExpiresAt time.Time, submittedBy string) *Identification {
+	now := time.Now()
+	return &Identification{
+		ID:                uuid.New(),
+		WalletID:          walletID,
+		DocumentType:      documentType,
+		DocumentNumber:    documentNumber,
+		DocumentExpiresAt: documentExpiresAt,
+		Status:            IdentificationPending,
+		SubmittedBy:       submittedBy,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// ExpiredOn reports whether the document has expired by day, a date at
+// midnight UTC like DocumentExpiresAt. Documents are valid through their
+// expiry date.
+func (i *Identification) ExpiredOn(day time.Time) bool {
+	return i.DocumentExpiresAt.Before(day)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeWallet LedgerAccountType = "WALLET"
+	LedgerAccountTypeSystem LedgerAccountType = "SYSTEM"
+)
+
+// System account codes. Each exists once per currency.
+const (
+	// SystemAccountPartnerFloat is the counterpart of money entering and
+	// leaving wallets through partners.
+	SystemAccountPartnerFloat = "PARTNER_FLOAT"
+	SystemAccountFees         = "FEES"
+	// SystemAccountSuspense holds amounts that could not be attributed yet,
+	// including the opening balances of wallets created before the ledger.
+	SystemAccountSuspense = "SUSPENSE"
+)
+
+var SystemAccountCodes = []string{SystemAccountPartnerFloat, SystemAccountFees, SystemAccountSuspense}
+
+var (
+	ErrUnbalancedEntry = errors.New("journal entry postings must sum to zero")
+	ErrEmptyEntry      = errors.New("journal entry needs at least two postings")
+)
+
+// LedgerAccount is an account of the double-entry ledger. Wallet accounts share
+// the ID of their wallet.
+type LedgerAccount struct {
+	ID        uuid.UUID         `json:"id"`
+	Type      LedgerAccountType `json:"type"`
+	Code      string            `json:"code,omitempty"`
+	WalletID  *uuid.UUID        `json:"wallet_id,omitempty"`
+	Currency  string            `json:"currency"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// JournalEntry is a set of postings that moves money between accounts. The
+// amounts of its postings always sum to zero.
+type JournalEntry struct {
+	ID          uuid.UUID  `json:"id"`
+	Description string     `json:"description"`
+	ReferenceID *uuid.UUID `json:"reference_id,omitempty"`
+	Postings    []*Posting `json:"postings"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Posting changes the balance of one account. A positive amount increases the
+// account balance, a negative one decreases it.
+type Posting struct {
+	ID             uuid.UUID `json:"id"`
+	JournalEntryID uuid.UUID `json:"journal_entry_id"`
+	AccountID      uuid.UUID `json:"account_id"`
+	Amount         Money     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewJournalEntry(description string, referenceID *uuid.UUID) *JournalEntry {
+	return &JournalEntry{
+		ID:          uuid.New(),
+		Description: description,
+		ReferenceID: referenceID,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Move adds the two postings that move amount from one account to another.
+func (e *JournalEntry) Move(from, to uuid.UUID, amount Money) *JournalEntry {
+	e.AddPosting(from, -amount)
+	e.AddPosting(to, amount)
+	return e
+}
+
+func (e *JournalEntry) AddPosting(accountID uuid.UUID, amount Money) {
+	e.Postings = append(e.Postings, &Posting{
+		ID:             uuid.New(),
+		JournalEntryID: e.ID,
+		AccountID:      accountID,
+		Amount:         amount,
+		CreatedAt:      e.CreatedAt,
+	})
+}
+
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrEmptyEntry
+	}
+
+	var sum Money
+	for _, posting := range e.Postings {
+		if posting.Amount == 0 {
+			return ErrInvalidAmount
+		}
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// BalanceMismatch reports a wallet whose cached balance differs from the sum
+// of its ledger postings.
+type BalanceMismatch struct {
+	WalletID      uuid.UUID `json:"wallet_id"`
+	Balance       Money     `json:"balance"`
+	LedgerBalance Money     `json:"ledger_balance"`
+}
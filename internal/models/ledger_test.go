@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	float, wallet := uuid.New(), uuid.New()
+
+	entry := NewJournalEntry("Top-up", nil).Move(float, wallet, NewMoney(10))
+	if err := entry.Validate(); err != nil {
+		t.Errorf("expected balanced entry to be valid; got %v", err)
+	}
+
+	entry.AddPosting(wallet, 1)
+	if err := entry.Validate(); !errors.Is(err, ErrUnbalancedEntry) {
+		t.Errorf("expected ErrUnbalancedEntry; got %v", err)
+	}
+
+	single := NewJournalEntry("Single", nil)
+	single.AddPosting(wallet, 0)
+	if err := single.Validate(); !errors.Is(err, ErrEmptyEntry) {
+		t.Errorf("expected ErrEmptyEntry; got %v", err)
+	}
+}
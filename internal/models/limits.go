@@ -0,0 +1,47 @@
+package models
+
+import "fmt"
+
+type LimitKind string
+
+const (
+	LimitMaxBalance      LimitKind = "MAX_BALANCE"
+	LimitSingleOperation LimitKind = "SINGLE_OPERATION"
+	LimitDailyTurnover   LimitKind = "DAILY_TURNOVER"
+	LimitMonthlyTurnover LimitKind = "MONTHLY_TURNOVER"
+)
+
+// TurnoverLimits caps the money entering a wallet. A zero value means the
+// limit is not enforced.
+type TurnoverLimits struct {
+	MaxOperation Money
+	Daily        Money
+	Monthly      Money
+}
+
+// LimitExceededError reports which limit an operation would break. Used is
+// what already counts against the limit and Remaining is the largest amount
+// that would still be accepted.
+type LimitExceededError struct {
+	Limit     LimitKind `json:"limit"`
+	Max       Money     `json:"max"`
+	Used      Money     `json:"used"`
+	Remaining Money     `json:"remaining"`
+}
+
+func NewLimitExceededError(limit LimitKind, max, used Money) *LimitExceededError {
+	remaining := max - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &LimitExceededError{Limit: limit, Max: max, Used: used, Remaining: remaining}
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit of %s exceeded, %s remaining", e.Limit, e.Max, e.Remaining)
+}
+
+// Is makes every limit error match ErrBalanceLimitExceeded.
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrBalanceLimitExceeded
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MoneyScale is the number of decimal places kept for every amount. It matches
+// the NUMERIC(15, 2) columns used for balances and transaction amounts.
+const MoneyScale = 2
+
+const (
+	minorUnitsPerMajor = 100
+	// maxMoney is the largest absolute value a NUMERIC(15, 2) column can hold.
+	maxMoney = Money(999_999_999_999_999)
+)
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrAmountScale   = fmt.Errorf("%w: at most %d decimal places are allowed", ErrInvalidAmount, MoneyScale)
+	ErrAmountRange   = fmt.Errorf("%w: out of range", ErrInvalidAmount)
+)
+
+// Money is an exact monetary amount expressed in minor units (1/100 of the
+// currency unit). It is encoded as NUMERIC in Postgres and as a decimal string
+// in JSON so amounts never pass through a float.
+type Money int64
+
+// NewMoney returns the amount for a whole number of currency units.
+func NewMoney(major int64) Money {
+	return Money(major * minorUnitsPerMajor)
+}
+
+// ParseMoney parses a decimal string such as "10", "10.5" or "-0.01".
+// Amounts with more than MoneyScale decimal places are rejected.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	if hasPoint && fracPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+	if len(fracPart) > MoneyScale {
+		return 0, ErrAmountScale
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(intPart) > 13 {
+		return 0, ErrAmountRange
+	}
+	fracPart += strings.Repeat("0", MoneyScale-len(fracPart))
+
+	minor, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, ErrAmountRange
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money(minor), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with exactly MoneyScale decimal places.
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorUnitsPerMajor, v%minorUnitsPerMajor)
+}
+
+func (m Money) IsPositive() bool {
+	return m > 0
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts both a JSON string ("10.50") and a JSON number (10.50).
+// Numbers are parsed from their literal text, so no precision is lost.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return ErrInvalidAmount
+	}
+
+	raw := string(data)
+	if strings.HasPrefix(raw, `"`) {
+		unquoted, err := strconv.Unquote(raw)
+		if err != nil {
+			return ErrInvalidAmount
+		}
+		raw = unquoted
+	}
+
+	parsed, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer.
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(m)), Exp: -MoneyScale, Valid: true}, nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner.
+func (m *Money) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return errors.New("cannot scan NULL into Money")
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("cannot scan %v into Money", n)
+	}
+
+	minor := new(big.Int).Set(n.Int)
+	shift := int64(n.Exp) + MoneyScale
+	if shift >= 0 {
+		minor.Mul(minor, new(big.Int).Exp(big.NewInt(10), big.NewInt(shift), nil))
+	} else {
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(-shift), nil)
+		var remainder big.Int
+		minor.QuoRem(minor, divisor, &remainder)
+		if remainder.Sign() != 0 {
+			return ErrAmountScale
+		}
+	}
+
+	if !minor.IsInt64() || Money(minor.Int64()) > maxMoney || Money(minor.Int64()) < -maxMoney {
+		return ErrAmountRange
+	}
+
+	*m = Money(minor.Int64())
+	return nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr error
+	}{
+		{input: "10", want: 1000},
+		{input: "10.5", want: 1050},
+		{input: "0.01", want: 1},
+		{input: "-0.10", want: -10},
+		{input: ".5", want: 50},
+		{input: "9999999999999.99", want: maxMoney},
+		{input: "1.234", wantErr: ErrAmountScale},
+		{input: "0.100", wantErr: ErrAmountScale},
+		{input: "10000000000000", wantErr: ErrAmountRange},
+		{input: "1e3", wantErr: ErrInvalidAmount},
+		{input: "1.", wantErr: ErrInvalidAmount},
+		{input: "", wantErr: ErrInvalidAmount},
+		{input: "abc", wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseMoney(%q) error = %v; want %v", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d; want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAddsExactly(t *testing.T) {
+	var sum Money
+	tenDirams, _ := ParseMoney("0.1")
+	for range 1000 {
+		sum += tenDirams
+	}
+	if sum.String() != "100.00" {
+		t.Errorf("expected 100.00; got %s", sum)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var req struct {
+		Amount Money `json:"amount"`
+	}
+
+	for _, body := range []string{`{"amount":"12.30"}`, `{"amount":12.3}`} {
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("unmarshal %s: %v", body, err)
+		}
+		if req.Amount != 1230 {
+			t.Errorf("unmarshal %s: got %d; want 1230", body, req.Amount)
+		}
+	}
+
+	err := json.Unmarshal([]byte(`{"amount":0.001}`), &req)
+	if !errors.Is(err, ErrAmountScale) {
+		t.Errorf("expected scale error; got %v", err)
+	}
+
+	out, err := json.Marshal(Money(-5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"-0.05"` {
+		t.Errorf("expected \"-0.05\"; got %s", out)
+	}
+}
+
+func TestMoneyNumericRoundTrip(t *testing.T) {
+	m := pgtype.NewMap()
+
+	for _, format := range []int16{pgtype.BinaryFormatCode, pgtype.TextFormatCode} {
+		buf, err := m.Encode(pgtype.NumericOID, format, Money(123456), nil)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+
+		var got Money
+		if err := m.Scan(pgtype.NumericOID, format, buf, &got); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if got != 123456 {
+			t.Errorf("format %d: got %s; want 1234.56", format, got)
+		}
+	}
+
+	var got Money
+	buf, _ := m.Encode(pgtype.NumericOID, pgtype.TextFormatCode, "1.005", nil)
+	if err := m.Scan(pgtype.NumericOID, pgtype.TextFormatCode, buf, &got); !errors.Is(err, ErrAmountScale) {
+		t.Errorf("expected scale error; got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone = errors.New("phone number must be in E.164 format, e.g. +992901234567")
+	// ErrAmbiguousPhone is returned for numbers that start with the default
+	// country code but have no + or 00: they could be international numbers
+	// missing the + or national numbers that happen to start with those digits.
+	ErrAmbiguousPhone = errors.New("phone number starts with the country code; prefix it with + or give the national number")
+)
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// phoneSeparators are stripped before a phone number is validated.
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// IsE164 reports whether phone is a number in E.164 format.
+func IsE164(phone string) bool {
+	return e164Pattern.MatchString(phone)
+}
+
+// NormalizePhone converts a phone number as typed by a person to E.164.
+// Separators are removed, an international 00 prefix becomes +, and numbers
+// without either are taken to be national numbers in defaultCountryCode,
+// with an optional trunk prefix 0. Numbers that already start with the
+// country code are rejected with ErrAmbiguousPhone rather than getting it
+// twice.
+func NormalizePhone(phone, defaultCountryCode string) (string, error) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	switch {
+	case strings.HasPrefix(phone, "+"):
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + phone[2:]
+	case defaultCountryCode != "" && strings.HasPrefix(phone, defaultCountryCode):
+		return "", ErrAmbiguousPhone
+	case defaultCountryCode != "":
+		phone = "+" + defaultCountryCode + strings.TrimPrefix(phone, "0")
+	}
+
+	if !IsE164(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+992 90 123-45-67", "+992901234567"},
+		{"00992901234567", "+992901234567"},
+		{"901234567", "+992901234567"},
+		{"(090) 123 45 67", "+992901234567"},
+		// The trunk prefix makes a national number starting with the
+		// country code unambiguous.
+		{"0992123456", "+992992123456"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.in, "992")
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "+0123456789", "+99290123abc", "12345"} {
+		if _, err := NormalizePhone(in, ""); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q): expected ErrInvalidPhone, got %v", in, err)
+		}
+	}
+
+	// Without + or 00 these would otherwise get the country code twice.
+	for _, in := range []string{"992901234567", "992 90 123 45 67"} {
+		if _, err := NormalizePhone(in, "992"); !errors.Is(err, ErrAmbiguousPhone) {
+			t.Errorf("NormalizePhone(%q): expected ErrAmbiguousPhone, got %v", in, err)
+		}
+	}
+}
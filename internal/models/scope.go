@@ -0,0 +1,46 @@
+package models
+
+import "fmt"
+
+// Scope is a permission granted to a client.
+type Scope string
+
+const (
+	ScopeWalletCreate   Scope = "wallet:create"
+	ScopeWalletRead     Scope = "wallet:read"
+	ScopeWalletTopUp    Scope = "wallet:topup"
+	ScopeWalletWithdraw Scope = "wallet:withdraw"
+	ScopeWalletTransfer Scope = "wallet:transfer"
+	ScopeWalletIdentify Scope = "wallet:identify"
+	ScopeWalletHold     Scope = "wallet:hold"
+)
+
+// AllScopes lists every scope. Clients created without explicit scopes get
+// all of them, as every client could call every endpoint before scopes existed.
+var AllScopes = []Scope{ScopeWalletCreate, ScopeWalletRead, ScopeWalletTopUp, ScopeWalletWithdraw, ScopeWalletTransfer, ScopeWalletIdentify, ScopeWalletHold}
+
+func (s Scope) Valid() bool {
+	for _, scope := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes validates the scopes and removes duplicates.
+func ParseScopes(values []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(values))
+	seen := make(map[Scope]bool, len(values))
+	for _, v := range values {
+		scope := Scope(v)
+		if !scope.Valid() {
+			return nil, fmt.Errorf("unknown scope %q", v)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
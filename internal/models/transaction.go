@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,28 +10,137 @@ import (
 type TransactionType string
 
 const (
-	TransactionTypeTopUp TransactionType = "TOP_UP"
-	// TransactionTypeWithdraw TransactionType = "WITHDRAW"
+	TransactionTypeTopUp       TransactionType = "TOP_UP"
+	TransactionTypeWithdraw    TransactionType = "WITHDRAW"
+	TransactionTypeTransferOut TransactionType = "TRANSFER_OUT"
+	TransactionTypeTransferIn  TransactionType = "TRANSFER_IN"
+	// TransactionTypeHoldCapture settles a hold placed on the wallet.
+	TransactionTypeHoldCapture TransactionType = "HOLD_CAPTURE"
+	// TransactionTypeReversalOut and TransactionTypeReversalIn undo part or
+	// all of an earlier transaction that put money into or took money out of
+	// the wallet respectively.
+	TransactionTypeReversalOut TransactionType = "REVERSAL_OUT"
+	TransactionTypeReversalIn  TransactionType = "REVERSAL_IN"
 )
 
+var (
+	ErrTransactionNotReversible   = errors.New("reversals cannot be reversed")
+	ErrTransactionAlreadyReversed = errors.New("transaction has already been reversed in full")
+	ErrReversalExceedsAmount      = errors.New("reversal exceeds the amount not yet reversed")
+)
+
+func (t TransactionType) Valid() bool {
+	switch t {
+	case TransactionTypeTopUp, TransactionTypeWithdraw, TransactionTypeTransferOut, TransactionTypeTransferIn, TransactionTypeHoldCapture,
+		TransactionTypeReversalOut, TransactionTypeReversalIn:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsDebit reports whether transactions of this type take money out of the wallet.
+func (t TransactionType) IsDebit() bool {
+	switch t {
+	case TransactionTypeWithdraw, TransactionTypeTransferOut, TransactionTypeHoldCapture, TransactionTypeReversalOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReversalType is the type of the transaction that compensates one of type t.
+func (t TransactionType) ReversalType() TransactionType {
+	if t.IsDebit() {
+		return TransactionTypeReversalIn
+	}
+	return TransactionTypeReversalOut
+}
+
 type Transaction struct {
 	ID          uuid.UUID       `json:"id"`
 	WalletID    uuid.UUID       `json:"wallet_id"`
 	Type        TransactionType `json:"type"`
-	Amount      float64         `json:"amount"`
+	Amount      Money           `json:"amount"`
 	Description string          `json:"description"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	TransferID  *uuid.UUID      `json:"transfer_id,omitempty"`
+	// ReversalOf is the transaction that a reversal compensates.
+	ReversalOf *uuid.UUID `json:"reversal_of,omitempty"`
+	// ReversedAmount is how much of the transaction has been reversed so far.
+	ReversedAmount Money `json:"reversed_amount"`
+	// ClientID is the partner that initiated the transaction, if any.
+	ClientID *uuid.UUID `json:"-"`
+	// ExternalRef is the initiating partner's own reference for the
+	// transaction, unique per partner.
+	ExternalRef *string           `json:"external_ref,omitempty"`
+	Status      TransactionStatus `json:"status"`
+	// StatusChangedAt is when the transaction got its current status.
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	// FailureReason says why a FAILED transaction failed.
+	FailureReason *string   `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
-func NewTransaction(walletID uuid.UUID, transactionType TransactionType, amount float64, description string) *Transaction {
+// NewTransaction returns a COMPLETED transaction. Use NewPendingTransaction
+// for operations that settle later.
+func NewTransaction(walletID uuid.UUID, transactionType TransactionType, amount Money, description string) *Transaction {
+	now := time.Now()
 	return &Transaction{
-		ID:          uuid.New(),
-		WalletID:    walletID,
-		Type:        transactionType,
-		Amount:      amount,
-		Description: description,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              uuid.New(),
+		WalletID:        walletID,
+		Type:            transactionType,
+		Amount:          amount,
+		Description:     description,
+		Status:          TransactionStatusCompleted,
+		StatusChangedAt: now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func NewPendingTransaction(walletID uuid.UUID, transactionType TransactionType, amount Money, description string) *Transaction {
+	transaction := NewTransaction(walletID, transactionType, amount, description)
+	transaction.Status = TransactionStatusPending
+	return transaction
+}
+
+// NewReversal returns the transaction that reverses amount of the original.
+func NewReversal(original *Transaction, amount Money, description string) *Transaction {
+	reversal := NewTransaction(original.WalletID, original.Type.ReversalType(), amount, description)
+	reversal.ReversalOf = &original.ID
+	return reversal
+}
+
+// Reverse records that amount more of the transaction has been reversed. A
+// zero amount reverses whatever is left, and the transaction becomes REVERSED
+// once nothing is left.
+func (t *Transaction) Reverse(amount Money, now time.Time) (Money, error) {
+	if t.ReversalOf != nil {
+		return 0, ErrTransactionNotReversible
+	}
+	if t.Status == TransactionStatusReversed {
+		return 0, ErrTransactionAlreadyReversed
+	}
+	if t.Status != TransactionStatusCompleted {
+		return 0, ErrTransactionNotCompleted
+	}
+	remaining := t.Amount - t.ReversedAmount
+	if remaining <= 0 {
+		return 0, ErrTransactionAlreadyReversed
+	}
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return 0, ErrReversalExceedsAmount
+	}
+	t.ReversedAmount += amount
+	t.UpdatedAt = now
+	if t.ReversedAmount == t.Amount {
+		if err := t.transition(TransactionStatusReversed, now); err != nil {
+			return 0, err
+		}
 	}
+	return amount, nil
 }
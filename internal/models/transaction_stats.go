@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type StatsPeriod string
+
+const (
+	StatsPeriodDay   StatsPeriod = "day"
+	StatsPeriodWeek  StatsPeriod = "week"
+	StatsPeriodMonth StatsPeriod = "month"
+	StatsPeriodYear  StatsPeriod = "year"
+)
+
+var (
+	ErrInvalidStatsPeriod = errors.New("period must be one of day, week, month or year")
+	ErrInvalidStatsRange  = errors.New("from must be before to")
+)
+
+// Bounds returns the half-open interval [from, to) of the calendar period
+// containing now, in the given location. Weeks start on Monday.
+func (p StatsPeriod) Bounds(now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	now = now.In(loc)
+	year, month, day := now.Date()
+
+	switch p {
+	case StatsPeriodDay:
+		from := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 0, 1), nil
+	case StatsPeriodWeek:
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		from := time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 0, 7), nil
+	case StatsPeriodMonth:
+		from := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 1, 0), nil
+	case StatsPeriodYear:
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(1, 0, 0), nil
+	default:
+		return time.Time{}, time.Time{}, ErrInvalidStatsPeriod
+	}
+}
+
+// StatsQuery selects the interval to aggregate. An explicit From/To range
+// takes precedence over Period.
+type StatsQuery struct {
+	Period   StatsPeriod
+	From     *time.Time
+	To       *time.Time
+	Location *time.Location
+}
+
+// TransactionStats aggregates the transactions of one type. Average is rounded
+// to MoneyScale decimal places.
+type TransactionStats struct {
+	Type    TransactionType `json:"type"`
+	Count   int             `json:"count"`
+	Sum     Money           `json:"sum"`
+	Min     Money           `json:"min"`
+	Max     Money           `json:"max"`
+	Average Money           `json:"average"`
+}
+
+type StatsReport struct {
+	From     time.Time                             `json:"from"`
+	To       time.Time                             `json:"to"`
+	Timezone string                                `json:"timezone"`
+	Stats    map[TransactionType]*TransactionStats `json:"stats"`
+}
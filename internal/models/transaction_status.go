@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TransactionStatus string
+
+// A transaction starts out PENDING and is settled to COMPLETED or FAILED; only
+// completed transactions have changed the wallet's balance. A COMPLETED
+// transaction becomes REVERSED once reversals have undone all of it.
+// Transactions of synchronous operations are created COMPLETED.
+const (
+	TransactionStatusPending   TransactionStatus = "PENDING"
+	TransactionStatusCompleted TransactionStatus = "COMPLETED"
+	TransactionStatusFailed    TransactionStatus = "FAILED"
+	TransactionStatusReversed  TransactionStatus = "REVERSED"
+)
+
+var (
+	ErrInvalidTransactionTransition = errors.New("transaction status transition is not allowed")
+	ErrTransactionNotCompleted      = errors.New("only completed transactions can be reversed")
+)
+
+// transactionStatusTransitions lists the statuses each status may change to.
+// FAILED and REVERSED are final.
+var transactionStatusTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusPending:   {TransactionStatusCompleted, TransactionStatusFailed},
+	TransactionStatusCompleted: {TransactionStatusReversed},
+}
+
+func (s TransactionStatus) Valid() bool {
+	_, ok := transactionStatusTransitions[s]
+	return ok || s == TransactionStatusFailed || s == TransactionStatusReversed
+}
+
+func (s TransactionStatus) CanTransitionTo(to TransactionStatus) bool {
+	for _, allowed := range transactionStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete settles a pending transaction. The caller applies its balance
+// effect in the same database transaction.
+func (t *Transaction) Complete(now time.Time) error {
+	return t.transition(TransactionStatusCompleted, now)
+}
+
+// Fail settles a pending transaction without any balance effect.
+func (t *Transaction) Fail(reason string, now time.Time) error {
+	if err := t.transition(TransactionStatusFailed, now); err != nil {
+		return err
+	}
+	t.FailureReason = &reason
+	return nil
+}
+
+func (t *Transaction) transition(to TransactionStatus, now time.Time) error {
+	if !t.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransactionTransition, t.Status, to)
+	}
+	t.Status = to
+	t.StatusChangedAt = now
+	t.UpdatedAt = now
+	return nil
+}
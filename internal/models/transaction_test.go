@@ -1 +1,143 @@
 package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	cursor := &TransactionCursor{
+		CreatedAt: time.Date(2024, 8, 31, 23, 59, 59, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := DecodeTransactionCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("failed to decode cursor: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("expected %+v; got %+v", cursor, decoded)
+	}
+
+	if _, err := DecodeTransactionCursor("not-a-cursor"); err == nil {
+		t.Error("expected an error for a malformed cursor")
+	}
+}
+
+func TestStatsPeriodBounds(t *testing.T) {
+	dushanbe, err := time.LoadLocation("Asia/Dushanbe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Still August 31st in UTC, but already September 1st in Dushanbe.
+	septemberFirst := time.Date(2024, 8, 31, 20, 30, 0, 0, time.UTC)
+	// A minute before midnight on Saturday, August 31st in Dushanbe.
+	augustLast := time.Date(2024, 8, 31, 18, 59, 0, 0, time.UTC)
+	// Already 2025 in Dushanbe; January 1st is a Wednesday.
+	newYear := time.Date(2024, 12, 31, 19, 30, 0, 0, time.UTC)
+	// February 29th of a leap year, shortly after midnight in Dushanbe.
+	leapDay := time.Date(2024, 2, 28, 19, 5, 0, 0, time.UTC)
+	// Berlin moves to summer time on March 31st, 2024, which has 23 hours.
+	dstDay := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		loc      *time.Location
+		period   StatsPeriod
+		from, to string
+	}{
+		{"September 1st day", septemberFirst, dushanbe, StatsPeriodDay, "2024-09-01T00:00:00+05:00", "2024-09-02T00:00:00+05:00"},
+		{"September 1st week", septemberFirst, dushanbe, StatsPeriodWeek, "2024-08-26T00:00:00+05:00", "2024-09-02T00:00:00+05:00"},
+		{"September 1st month", septemberFirst, dushanbe, StatsPeriodMonth, "2024-09-01T00:00:00+05:00", "2024-10-01T00:00:00+05:00"},
+		{"September 1st year", septemberFirst, dushanbe, StatsPeriodYear, "2024-01-01T00:00:00+05:00", "2025-01-01T00:00:00+05:00"},
+		{"same instant in UTC", septemberFirst, time.UTC, StatsPeriodMonth, "2024-08-01T00:00:00Z", "2024-09-01T00:00:00Z"},
+		{"before midnight day", augustLast, dushanbe, StatsPeriodDay, "2024-08-31T00:00:00+05:00", "2024-09-01T00:00:00+05:00"},
+		{"before midnight week", augustLast, dushanbe, StatsPeriodWeek, "2024-08-26T00:00:00+05:00", "2024-09-02T00:00:00+05:00"},
+		{"before midnight month", augustLast, dushanbe, StatsPeriodMonth, "2024-08-01T00:00:00+05:00", "2024-09-01T00:00:00+05:00"},
+		{"new year day", newYear, dushanbe, StatsPeriodDay, "2025-01-01T00:00:00+05:00", "2025-01-02T00:00:00+05:00"},
+		{"new year week", newYear, dushanbe, StatsPeriodWeek, "2024-12-30T00:00:00+05:00", "2025-01-06T00:00:00+05:00"},
+		{"new year month", newYear, dushanbe, StatsPeriodMonth, "2025-01-01T00:00:00+05:00", "2025-02-01T00:00:00+05:00"},
+		{"new year year", newYear, dushanbe, StatsPeriodYear, "2025-01-01T00:00:00+05:00", "2026-01-01T00:00:00+05:00"},
+		{"leap day", leapDay, dushanbe, StatsPeriodDay, "2024-02-29T00:00:00+05:00", "2024-03-01T00:00:00+05:00"},
+		{"leap month", leapDay, dushanbe, StatsPeriodMonth, "2024-02-01T00:00:00+05:00", "2024-03-01T00:00:00+05:00"},
+		{"DST day", dstDay, berlin, StatsPeriodDay, "2024-03-31T00:00:00+01:00", "2024-04-01T00:00:00+02:00"},
+		{"DST week", dstDay, berlin, StatsPeriodWeek, "2024-03-25T00:00:00+01:00", "2024-04-01T00:00:00+02:00"},
+		{"DST month", dstDay, berlin, StatsPeriodMonth, "2024-03-01T00:00:00+01:00", "2024-04-01T00:00:00+02:00"},
+	}
+
+	for _, tt := range tests {
+		from, to, err := tt.period.Bounds(tt.now, tt.loc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if from.Format(time.RFC3339) != tt.from || to.Format(time.RFC3339) != tt.to {
+			t.Errorf("%s: got [%s, %s); want [%s, %s)", tt.name, from.Format(time.RFC3339), to.Format(time.RFC3339), tt.from, tt.to)
+		}
+	}
+
+	if _, _, err := StatsPeriod("decade").Bounds(septemberFirst, dushanbe); err != ErrInvalidStatsPeriod {
+		t.Errorf("expected ErrInvalidStatsPeriod; got %v", err)
+	}
+}
+
+func TestTransactionReverse(t *testing.T) {
+	now := time.Now()
+	original := NewTransaction(uuid.New(), TransactionTypeTopUp, NewMoney(10), "Top-up")
+
+	if got, err := original.Reverse(NewMoney(4), now); err != nil || got != NewMoney(4) {
+		t.Fatalf("partial reversal: got %s, %v", got, err)
+	}
+	if _, err := original.Reverse(NewMoney(7), now); !errors.Is(err, ErrReversalExceedsAmount) {
+		t.Fatalf("expected ErrReversalExceedsAmount, got %v", err)
+	}
+	if got, err := original.Reverse(0, now); err != nil || got != NewMoney(6) {
+		t.Fatalf("reversal of the rest: got %s, %v", got, err)
+	}
+	if original.Status != TransactionStatusReversed {
+		t.Fatalf("expected a fully reversed transaction to be REVERSED, got %s", original.Status)
+	}
+	if _, err := original.Reverse(0, now); !errors.Is(err, ErrTransactionAlreadyReversed) {
+		t.Fatalf("expected ErrTransactionAlreadyReversed, got %v", err)
+	}
+
+	reversal := NewReversal(original, NewMoney(10), "Refund")
+	if reversal.Type != TransactionTypeReversalOut || *reversal.ReversalOf != original.ID {
+		t.Fatalf("unexpected reversal: %+v", reversal)
+	}
+	if _, err := reversal.Reverse(0, now); !errors.Is(err, ErrTransactionNotReversible) {
+		t.Fatalf("expected ErrTransactionNotReversible, got %v", err)
+	}
+}
+
+func TestTransactionStatusTransitions(t *testing.T) {
+	now := time.Now()
+
+	pending := NewPendingTransaction(uuid.New(), TransactionTypeWithdraw, NewMoney(10), "Payout")
+	if _, err := pending.Reverse(0, now); !errors.Is(err, ErrTransactionNotCompleted) {
+		t.Fatalf("expected ErrTransactionNotCompleted reversing a pending transaction, got %v", err)
+	}
+	if err := pending.Fail("Rejected by the bank", now); err != nil {
+		t.Fatalf("failed to fail pending transaction: %v", err)
+	}
+	if pending.Status != TransactionStatusFailed || *pending.FailureReason != "Rejected by the bank" {
+		t.Fatalf("unexpected transaction after failure: %+v", pending)
+	}
+	if err := pending.Complete(now); !errors.Is(err, ErrInvalidTransactionTransition) {
+		t.Fatalf("expected ErrInvalidTransactionTransition completing a failed transaction, got %v", err)
+	}
+
+	completed := NewTransaction(uuid.New(), TransactionTypeTopUp, NewMoney(10), "Top-up")
+	if err := completed.Complete(now); !errors.Is(err, ErrInvalidTransactionTransition) {
+		t.Fatalf("expected ErrInvalidTransactionTransition completing twice, got %v", err)
+	}
+}
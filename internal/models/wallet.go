@@ -14,19 +14,40 @@ const (
 	WalletTypeUnidentified WalletType = "UNIDENTIFIED"
 )
 
+func (t WalletType) Valid() bool {
+	return t == WalletTypeIdentified || t == WalletTypeUnidentified
+}
+
+var (
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrBalanceLimitExceeded = errors.New("balance exceeds maximum limit")
+)
+
 type Wallet struct {
-	ID        uuid.UUID  `json:"id"`
-	Type      WalletType `json:"type"`
-	Balance   float64    `json:"balance"`
-	Currency  string     `json:"currency"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID uuid.UUID `json:"id"`
+	// ClientID is the partner that owns the wallet. Other clients can use it
+	// only when they have been granted access. It is uuid.Nil for wallets
+	// that predate ownership until an admin assigns their owner.
+	ClientID uuid.UUID    `json:"client_id"`
+	Type     WalletType   `json:"type"`
+	Status   WalletStatus `json:"status"`
+	Balance  Money        `json:"balance"`
+	Currency string       `json:"currency"`
+	// ExternalRef is the owning partner's own account reference, unique per
+	// partner.
+	ExternalRef *string `json:"external_ref,omitempty"`
+	// CustomerID is the holder of the wallet, if known.
+	CustomerID *uuid.UUID `json:"customer_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
-func NewWallet(walletType WalletType, currency string) *Wallet {
+func NewWallet(clientID uuid.UUID, walletType WalletType, currency string) *Wallet {
 	return &Wallet{
 		ID:        uuid.New(),
+		ClientID:  clientID,
 		Type:      walletType,
+		Status:    WalletStatusActive,
 		Balance:   0,
 		Currency:  currency,
 		CreatedAt: time.Now(),
@@ -34,24 +55,47 @@ func NewWallet(walletType WalletType, currency string) *Wallet {
 	}
 }
 
-func (w *Wallet) getMaxBalance() float64 {
-	switch w.Type {
-	case WalletTypeIdentified:
-		return 100_000
-	case WalletTypeUnidentified:
-		return 10_000
-	default:
-		return 0
+// CanCredit fails with ErrCreditsNotAllowed unless the wallet's status lets
+// money in.
+func (w *Wallet) CanCredit() error {
+	if !w.Status.AllowsCredits() {
+		return ErrCreditsNotAllowed
 	}
+	return nil
+}
+
+// CanDebit fails with ErrDebitsNotAllowed unless the wallet's status lets
+// money out.
+func (w *Wallet) CanDebit() error {
+	if !w.Status.AllowsDebits() {
+		return ErrDebitsNotAllowed
+	}
+	return nil
 }
 
-func (w *Wallet) UpdateBalance(amount float64) error {
+// Credit adds amount to the balance unless the wallet's status refuses
+// credits or the result would exceed maxBalance.
+func (w *Wallet) Credit(amount, maxBalance Money) error {
+	if err := w.CanCredit(); err != nil {
+		return err
+	}
 	newBalance := w.Balance + amount
-	if newBalance < 0 {
-		return errors.New("insufficient funds")
+	if newBalance > maxBalance {
+		return ErrBalanceLimitExceeded
+	}
+	w.Balance = newBalance
+	return nil
+}
+
+// Debit subtracts amount from the balance unless the wallet's status refuses
+// debits or the balance does not cover it.
+func (w *Wallet) Debit(amount Money) error {
+	if err := w.CanDebit(); err != nil {
+		return err
 	}
-	if newBalance > w.getMaxBalance() {
-		return errors.New("balance exceeds maximum limit")
+	newBalance := w.Balance - amount
+	if newBalance < 0 {
+		return ErrInsufficientFunds
 	}
 	w.Balance = newBalance
 	return nil
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WalletStatus string
+
+// ACTIVE wallets accept every operation. FROZEN_CREDITS and FROZEN_DEBITS
+// wallets refuse money coming in or going out respectively, BLOCKED wallets
+// refuse both, and CLOSED wallets refuse everything for good.
+const (
+	WalletStatusActive        WalletStatus = "ACTIVE"
+	WalletStatusFrozenCredits WalletStatus = "FROZEN_CREDITS"
+	WalletStatusFrozenDebits  WalletStatus = "FROZEN_DEBITS"
+	WalletStatusBlocked       WalletStatus = "BLOCKED"
+	WalletStatusClosed        WalletStatus = "CLOSED"
+)
+
+var (
+	ErrCreditsNotAllowed       = errors.New("wallet does not accept credits in its current status")
+	ErrDebitsNotAllowed        = errors.New("wallet does not allow debits in its current status")
+	ErrInvalidStatusTransition = errors.New("wallet status transition is not allowed")
+)
+
+// walletStatusTransitions lists the statuses each status may change to.
+// CLOSED is final.
+var walletStatusTransitions = map[WalletStatus][]WalletStatus{
+	WalletStatusActive:        {WalletStatusFrozenCredits, WalletStatusFrozenDebits, WalletStatusBlocked, WalletStatusClosed},
+	WalletStatusFrozenCredits: {WalletStatusActive, WalletStatusFrozenDebits, WalletStatusBlocked, WalletStatusClosed},
+	WalletStatusFrozenDebits:  {WalletStatusActive, WalletStatusFrozenCredits, WalletStatusBlocked, WalletStatusClosed},
+	WalletStatusBlocked:       {WalletStatusActive, WalletStatusFrozenCredits, WalletStatusFrozenDebits, WalletStatusClosed},
+}
+
+func (s WalletStatus) Valid() bool {
+	_, ok := walletStatusTransitions[s]
+	return ok || s == WalletStatusClosed
+}
+
+func (s WalletStatus) CanTransitionTo(to WalletStatus) bool {
+	for _, allowed := range walletStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s WalletStatus) AllowsCredits() bool {
+	return s == WalletStatusActive || s == WalletStatusFrozenDebits
+}
+
+func (s WalletStatus) AllowsDebits() bool {
+	return s == WalletStatusActive || s == WalletStatusFrozenCredits
+}
+
+// WalletStatusChange records a transition of a wallet's status, who made it
+// and why.
+type WalletStatusChange struct {
+	ID         uuid.UUID    `json:"id"`
+	WalletID   uuid.UUID    `json:"wallet_id"`
+	FromStatus WalletStatus `json:"from_status"`
+	ToStatus   WalletStatus `json:"to_status"`
+	Reason     string       `json:"reason"`
+	Actor      string       `json:"actor"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+func NewWalletStatusChange(walletID uuid.UUID, from, to WalletStatus, reason, actor string) *WalletStatusChange {
+	return &WalletStatusChange{
+		ID:         uuid.New(),
+		WalletID:   walletID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		Actor:      actor,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestWalletStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to WalletStatus
+		allowed  bool
+	}{
+		{WalletStatusActive, WalletStatusFrozenCredits, true},
+		{WalletStatusFrozenDebits, WalletStatusActive, true},
+		{WalletStatusBlocked, WalletStatusClosed, true},
+		{WalletStatusActive, WalletStatusActive, false},
+		{WalletStatusClosed, WalletStatusActive, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: got %v; want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+
+	if !WalletStatusFrozenDebits.AllowsCredits() || WalletStatusFrozenDebits.AllowsDebits() {
+		t.Error("FROZEN_DEBITS wallets must accept credits only")
+	}
+	if WalletStatusBlocked.AllowsCredits() || WalletStatusBlocked.AllowsDebits() {
+		t.Error("BLOCKED wallets must refuse credits and debits")
+	}
+}
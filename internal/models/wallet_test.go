@@ -1 +1,34 @@
 package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWalletCreditAndDebit(t *testing.T) {
+	wallet := NewWallet(uuid.New(), WalletTypeUnidentified, "TJS")
+	maxBalance := NewMoney(10_000)
+
+	if err := wallet.Credit(NewMoney(10_000), maxBalance); err != nil {
+		t.Fatalf("top-up up to the limit failed: %v", err)
+	}
+	if err := wallet.Credit(1, maxBalance); !errors.Is(err, ErrBalanceLimitExceeded) {
+		t.Errorf("expected ErrBalanceLimitExceeded; got %v", err)
+	}
+	if err := wallet.Debit(NewMoney(10_000) + 1); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds; got %v", err)
+	}
+	if err := wallet.Debit(NewMoney(10_000)); err != nil {
+		t.Errorf("withdrawing the whole balance failed: %v", err)
+	}
+	if wallet.Balance != 0 {
+		t.Errorf("expected zero balance; got %s", wallet.Balance)
+	}
+
+	wallet.Status = WalletStatusFrozenCredits
+	if err := wallet.Credit(1, maxBalance); !errors.Is(err, ErrCreditsNotAllowed) {
+		t.Errorf("expected ErrCreditsNotAllowed; got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	GetByTarget(ctx context.Context, targetType string, targetID uuid.UUID) ([]*models.AuditEntry, error)
+}
+
+type PostgresAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(pool *pgxpool.Pool) *PostgresAuditRepository {
+	return &PostgresAuditRepository{pool: pool}
+}
+
+func (r *PostgresAuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO audit_log (id, actor, action, target_type, target_id, details, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		entry.ID, entry.Actor, entry.Action, entry.TargetType, entry.TargetID, entry.Details, entry.CreatedAt)
+	return err
+}
+
+// GetByTarget returns the audit trail of one object, newest first.
+func (r *PostgresAuditRepository) GetByTarget(ctx context.Context, targetType string, targetID uuid.UUID) ([]*models.AuditEntry, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		"SELECT id, actor, action, target_type, target_id, details, created_at FROM audit_log WHERE target_type = $1 AND target_id = $2 ORDER BY created_at DESC",
+		targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		e := &models.AuditEntry{}
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ClientCredentialRepository interface {
+	Create(ctx context.Context, credential *models.ClientCredential) error
+	GetByID(ctx context.Context, clientID, id uuid.UUID) (*models.ClientCredential, error)
+	GetByClientID(ctx context.Context, clientID uuid.UUID) ([]*models.ClientCredential, error)
+	GetValid(ctx context.Context, clientID uuid.UUID, at time.Time) ([]*models.ClientCredential, error)
+	GetNotEncryptedWith(ctx context.Context, keyID string) ([]*models.ClientCredential, error)
+	GetPlaintext(ctx context.Context) ([]*models.ClientCredential, error)
+	UpdateSecret(ctx context.Context, credential *models.ClientCredential) error
+	ExpireValid(ctx context.Context, clientID uuid.UUID, exceptID uuid.UUID, expiresAt time.Time) error
+	Revoke(ctx context.Context, clientID, id uuid.UUID, revokedAt time.Time) (bool, error)
+}
+
+type PostgresClientCredentialRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresClientCredentialRepository(pool *pgxpool.Pool) *PostgresClientCredentialRepository {
+	return &PostgresClientCredentialRepository{pool: pool}
+}
+
+const clientCredentialColumns = "id, client_id, secret, secret_key_id, not_before, expires_at, revoked_at, created_at"
+
+func scanClientCredential(row pgx.Row) (*models.ClientCredential, error) {
+	c := &models.ClientCredential{}
+	err := row.Scan(&c.ID, &c.ClientID, &c.EncryptedSecret, &c.SecretKeyID, &c.NotBefore, &c.ExpiresAt, &c.RevokedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *PostgresClientCredentialRepository) queryCredentials(ctx context.Context, sql string, args ...any) ([]*models.ClientCredential, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []*models.ClientCredential
+	for rows.Next() {
+		c, err := scanClientCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+	return credentials, rows.Err()
+}
+
+// Create stores the credential with its EncryptedSecret; the plaintext Secret is never written.
+func (r *PostgresClientCredentialRepository) Create(ctx context.Context, credential *models.ClientCredential) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO client_credentials ("+clientCredentialColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		credential.ID, credential.ClientID, credential.EncryptedSecret, credential.SecretKeyID,
+		credential.NotBefore, credential.ExpiresAt, credential.RevokedAt, credential.CreatedAt)
+	return err
+}
+
+func (r *PostgresClientCredentialRepository) GetByID(ctx context.Context, clientID, id uuid.UUID) (*models.ClientCredential, error) {
+	return scanClientCredential(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+clientCredentialColumns+" FROM client_credentials WHERE client_id = $1 AND id = $2",
+		clientID, id))
+}
+
+// GetByClientID returns all credentials of the client, newest first.
+func (r *PostgresClientCredentialRepository) GetByClientID(ctx context.Context, clientID uuid.UUID) ([]*models.ClientCredential, error) {
+	return r.queryCredentials(ctx,
+		"SELECT "+clientCredentialColumns+" FROM client_credentials WHERE client_id = $1 ORDER BY not_before DESC, created_at DESC",
+		clientID)
+}
+
+// GetValid returns the credentials that may sign a request at the given time, newest first.
+func (r *PostgresClientCredentialRepository) GetValid(ctx context.Context, clientID uuid.UUID, at time.Time) ([]*models.ClientCredential, error) {
+	return r.queryCredentials(ctx, `
+		SELECT `+clientCredentialColumns+` FROM client_credentials
+		WHERE client_id = $1
+		  AND revoked_at IS NULL
+		  AND not_before <= $2
+		  AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY not_before DESC, created_at DESC
+	`, clientID, at)
+}
+
+// GetNotEncryptedWith returns the credentials whose secret is not encrypted
+// with the given master key, including legacy plaintext secrets.
+func (r *PostgresClientCredentialRepository) GetNotEncryptedWith(ctx context.Context, keyID string) ([]*models.ClientCredential, error) {
+	return r.queryCredentials(ctx,
+		"SELECT "+clientCredentialColumns+" FROM client_credentials WHERE secret_key_id IS DISTINCT FROM $1",
+		keyID)
+}
+
+// GetPlaintext returns the legacy credentials whose secret is not encrypted.
+func (r *PostgresClientCredentialRepository) GetPlaintext(ctx context.Context) ([]*models.ClientCredential, error) {
+	return r.queryCredentials(ctx,
+		"SELECT "+clientCredentialColumns+" FROM client_credentials WHERE secret_key_id IS NULL")
+}
+
+func (r *PostgresClientCredentialRepository) UpdateSecret(ctx context.Context, credential *models.ClientCredential) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE client_credentials SET secret = $1, secret_key_id = $2 WHERE id = $3",
+		credential.EncryptedSecret, credential.SecretKeyID, credential.ID)
+	return err
+}
+
+// ExpireValid makes every unrevoked credential of the client except exceptID
+// expire at expiresAt, unless it already expires earlier.
+func (r *PostgresClientCredentialRepository) ExpireValid(ctx context.Context, clientID uuid.UUID, exceptID uuid.UUID, expiresAt time.Time) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE client_credentials SET expires_at = $1
+		WHERE client_id = $2
+		  AND id <> $3
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > $1)
+	`, expiresAt, clientID, exceptID)
+	return err
+}
+
+// Revoke revokes the credential and reports whether it was found unrevoked.
+func (r *PostgresClientCredentialRepository) Revoke(ctx context.Context, clientID, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE client_credentials SET revoked_at = $1 WHERE client_id = $2 AND id = $3 AND revoked_at IS NULL",
+		revokedAt, clientID, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
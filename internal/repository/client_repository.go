@@ -6,14 +6,18 @@ import (
 	"github.com/mabduqayum/ewallet/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ClientRepository interface {
 	Create(ctx context.Context, client *models.Client) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Client, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Client, error)
 	GetByAPIKey(ctx context.Context, apiKey string) (*models.Client, error)
 	GetAll(ctx context.Context) ([]*models.Client, error)
+	List(ctx context.Context, limit, offset int) ([]*models.Client, error)
+	Count(ctx context.Context) (int, error)
 	Update(ctx context.Context, client *models.Client) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -26,37 +30,71 @@ func NewPostgresClientRepository(pool *pgxpool.Pool) *PostgresClientRepository {
 	return &PostgresClientRepository{pool: pool}
 }
 
-func (r *PostgresClientRepository) Create(ctx context.Context, client *models.Client) error {
-	_, err := r.pool.Exec(ctx,
-		"INSERT INTO clients (id, name, api_key, secret_key, active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
-		client.ID, client.Name, client.ApiKey, client.SecretKey, client.Active, client.CreatedAt, client.UpdatedAt)
-	return err
-}
+const clientColumns = "id, name, api_key, active, signature_scheme, scopes, created_at, updated_at"
 
-func (r *PostgresClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+func scanClient(row pgx.Row) (*models.Client, error) {
 	client := &models.Client{}
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, name, api_key, secret_key, active, created_at, updated_at FROM clients WHERE id = $1",
-		id).Scan(&client.ID, &client.Name, &client.ApiKey, &client.SecretKey, &client.Active, &client.CreatedAt, &client.UpdatedAt)
+	var scopes []string
+	err := row.Scan(&client.ID, &client.Name, &client.ApiKey, &client.Active, &client.SignatureScheme, &scopes, &client.CreatedAt, &client.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+	client.Scopes = make([]models.Scope, len(scopes))
+	for i, scope := range scopes {
+		client.Scopes[i] = models.Scope(scope)
+	}
 	return client, nil
 }
 
-func (r *PostgresClientRepository) GetByAPIKey(ctx context.Context, apiKey string) (*models.Client, error) {
-	client := &models.Client{}
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, name, api_key, secret_key, active, created_at, updated_at FROM clients WHERE api_key = $1",
-		apiKey).Scan(&client.ID, &client.Name, &client.ApiKey, &client.SecretKey, &client.Active, &client.CreatedAt, &client.UpdatedAt)
-	if err != nil {
-		return nil, err
+func scopeStrings(scopes []models.Scope) []string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
 	}
-	return client, nil
+	return values
+}
+
+func (r *PostgresClientRepository) Create(ctx context.Context, client *models.Client) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO clients ("+clientColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		client.ID, client.Name, client.ApiKey, client.Active, client.SignatureScheme, scopeStrings(client.Scopes), client.CreatedAt, client.UpdatedAt)
+	return err
+}
+
+func (r *PostgresClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	return scanClient(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+clientColumns+" FROM clients WHERE id = $1", id))
+}
+
+// GetByIDForUpdate locks the client row until the surrounding transaction ends.
+// It must be called inside Transactor.WithinTransaction.
+func (r *PostgresClientRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	return scanClient(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+clientColumns+" FROM clients WHERE id = $1 FOR UPDATE", id))
+}
+
+func (r *PostgresClientRepository) GetByAPIKey(ctx context.Context, apiKey string) (*models.Client, error) {
+	return scanClient(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+clientColumns+" FROM clients WHERE api_key = $1", apiKey))
 }
 
 func (r *PostgresClientRepository) GetAll(ctx context.Context) ([]*models.Client, error) {
-	rows, err := r.pool.Query(ctx, "SELECT id, name, api_key, secret_key, active, created_at, updated_at FROM clients")
+	return r.queryClients(ctx, "SELECT "+clientColumns+" FROM clients")
+}
+
+// List returns one page of clients, oldest first.
+func (r *PostgresClientRepository) List(ctx context.Context, limit, offset int) ([]*models.Client, error) {
+	return r.queryClients(ctx, "SELECT "+clientColumns+" FROM clients ORDER BY created_at, id LIMIT $1 OFFSET $2", limit, offset)
+}
+
+func (r *PostgresClientRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	err := conn(ctx, r.pool).QueryRow(ctx, "SELECT COUNT(*) FROM clients").Scan(&count)
+	return count, err
+}
+
+func (r *PostgresClientRepository) queryClients(ctx context.Context, sql string, args ...any) ([]*models.Client, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,25 +102,24 @@ func (r *PostgresClientRepository) GetAll(ctx context.Context) ([]*models.Client
 
 	var clients []*models.Client
 	for rows.Next() {
-		client := &models.Client{}
-		err := rows.Scan(&client.ID, &client.Name, &client.ApiKey, &client.SecretKey, &client.Active, &client.CreatedAt, &client.UpdatedAt)
+		client, err := scanClient(rows)
 		if err != nil {
 			return nil, err
 		}
 		clients = append(clients, client)
 	}
 
-	return clients, nil
+	return clients, rows.Err()
 }
 
 func (r *PostgresClientRepository) Update(ctx context.Context, client *models.Client) error {
-	_, err := r.pool.Exec(ctx,
-		"UPDATE clients SET name = $1, api_key = $2, secret_key = $3, active = $4, updated_at = $5 WHERE id = $6",
-		client.Name, client.ApiKey, client.SecretKey, client.Active, client.UpdatedAt, client.ID)
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE clients SET name = $1, api_key = $2, active = $3, signature_scheme = $4, scopes = $5, updated_at = $6 WHERE id = $7",
+		client.Name, client.ApiKey, client.Active, client.SignatureScheme, scopeStrings(client.Scopes), client.UpdatedAt, client.ID)
 	return err
 }
 
 func (r *PostgresClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.pool.Exec(ctx, "DELETE FROM clients WHERE id = $1", id)
+	_, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM clients WHERE id = $1", id)
 	return err
 }
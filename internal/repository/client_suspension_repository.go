@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ClientSuspensionRepository interface {
+	Create(ctx context.Context, suspension *models.ClientSuspension) error
+	GetCurrent(ctx context.Context, clientID uuid.UUID) (*models.ClientSuspension, error)
+	GetByClientID(ctx context.Context, clientID uuid.UUID) ([]*models.ClientSuspension, error)
+	Lift(ctx context.Context, id uuid.UUID, liftedBy string, liftedAt time.Time) error
+	GetDueForReactivation(ctx context.Context, now time.Time) ([]*models.ClientSuspension, error)
+}
+
+type PostgresClientSuspensionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresClientSuspensionRepository(pool *pgxpool.Pool) *PostgresClientSuspensionRepository {
+	return &PostgresClientSuspensionRepository{pool: pool}
+}
+
+const clientSuspensionColumns = "id, client_id, reason, suspended_at, suspended_by, reactivate_at, lifted_at, lifted_by"
+
+func scanClientSuspension(row pgx.Row) (*models.ClientSuspension, error) {
+	s := &models.ClientSuspension{}
+	err := row.Scan(&s.ID, &s.ClientID, &s.Reason, &s.SuspendedAt, &s.SuspendedBy, &s.ReactivateAt, &s.LiftedAt, &s.LiftedBy)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *PostgresClientSuspensionRepository) Create(ctx context.Context, suspension *models.ClientSuspension) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO client_suspensions (id, client_id, reason, suspended_at, suspended_by, reactivate_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		suspension.ID, suspension.ClientID, suspension.Reason, suspension.SuspendedAt, suspension.SuspendedBy, suspension.ReactivateAt)
+	return err
+}
+
+// GetCurrent returns the suspension in effect for the client, or pgx.ErrNoRows.
+func (r *PostgresClientSuspensionRepository) GetCurrent(ctx context.Context, clientID uuid.UUID) (*models.ClientSuspension, error) {
+	return scanClientSuspension(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+clientSuspensionColumns+" FROM client_suspensions WHERE client_id = $1 AND lifted_at IS NULL",
+		clientID))
+}
+
+// GetByClientID returns every suspension of the client, newest first.
+func (r *PostgresClientSuspensionRepository) GetByClientID(ctx context.Context, clientID uuid.UUID) ([]*models.ClientSuspension, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		"SELECT "+clientSuspensionColumns+" FROM client_suspensions WHERE client_id = $1 ORDER BY suspended_at DESC",
+		clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suspensions []*models.ClientSuspension
+	for rows.Next() {
+		s, err := scanClientSuspension(rows)
+		if err != nil {
+			return nil, err
+		}
+		suspensions = append(suspensions, s)
+	}
+	return suspensions, rows.Err()
+}
+
+func (r *PostgresClientSuspensionRepository) Lift(ctx context.Context, id uuid.UUID, liftedBy string, liftedAt time.Time) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE client_suspensions SET lifted_at = $1, lifted_by = $2 WHERE id = $3 AND lifted_at IS NULL",
+		liftedAt, liftedBy, id)
+	return err
+}
+
+// GetDueForReactivation returns the suspensions in effect whose scheduled
+// reactivation time has passed.
+func (r *PostgresClientSuspensionRepository) GetDueForReactivation(ctx context.Context, now time.Time) ([]*models.ClientSuspension, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		"SELECT "+clientSuspensionColumns+" FROM client_suspensions WHERE lifted_at IS NULL AND reactivate_at <= $1",
+		now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suspensions []*models.ClientSuspension
+	for rows.Next() {
+		s, err := scanClientSuspension(rows)
+		if err != nil {
+			return nil, err
+		}
+		suspensions = append(suspensions, s)
+	}
+	return suspensions, rows.Err()
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CustomerRepository interface {
+	GetOrCreate(ctx context.Context, customer *models.Customer) (*models.Customer, error)
+	FillDetails(ctx context.Context, id uuid.UUID, name *string, dateOfBirth *time.Time) (*models.Customer, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error)
+	GetByPhone(ctx context.Context, phone string) (*models.Customer, error)
+	RefreshIdentificationStatus(ctx context.Context, id uuid.UUID) error
+}
+
+type PostgresCustomerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresCustomerRepository(pool *pgxpool.Pool) *PostgresCustomerRepository {
+	return &PostgresCustomerRepository{pool: pool}
+}
+
+const customerColumns = "id, phone, name, date_of_birth, identification_status, created_at, updated_at"
+
+func scanCustomer(row pgx.Row) (*models.Customer, error) {
+	c := &models.Customer{}
+	err := row.Scan(&c.ID, &c.Phone, &c.Name, &c.DateOfBirth, &c.IdentificationStatus, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetOrCreate stores the customer unless one with the same phone number
+// exists, and returns the stored customer. An existing customer is left as is.
+func (r *PostgresCustomerRepository) GetOrCreate(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO customers (`+customerColumns+`)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)
+         ON CONFLICT (phone) DO NOTHING`,
+		customer.ID, customer.Phone, customer.Name, customer.DateOfBirth, customer.IdentificationStatus,
+		customer.CreatedAt, customer.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+	return r.GetByPhone(ctx, customer.Phone)
+}
+
+// FillDetails sets the name and date of birth the customer does not have yet.
+// Known details are never overwritten.
+func (r *PostgresCustomerRepository) FillDetails(ctx context.Context, id uuid.UUID, name *string, dateOfBirth *time.Time) (*models.Customer, error) {
+	return scanCustomer(conn(ctx, r.pool).QueryRow(ctx,
+		`UPDATE customers
+         SET name = COALESCE(name, $1), date_of_birth = COALESCE(date_of_birth, $2), updated_at = $3
+         WHERE id = $4
+         RETURNING `+customerColumns,
+		name, dateOfBirth, time.Now(), id))
+}
+
+func (r *PostgresCustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Customer, error) {
+	return scanCustomer(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1", id))
+}
+
+func (r *PostgresCustomerRepository) GetByPhone(ctx context.Context, phone string) (*models.Customer, error) {
+	return scanCustomer(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE phone = $1", phone))
+}
+
+// RefreshIdentificationStatus marks the customer IDENTIFIED while any of the
+// customer's wallets has an approved identification and UNIDENTIFIED
+// otherwise. The customer row is locked first so that the status is computed
+// from a snapshot that includes the changes of concurrent refreshes.
+func (r *PostgresCustomerRepository) RefreshIdentificationStatus(ctx context.Context, id uuid.UUID) error {
+	db := conn(ctx, r.pool)
+	if _, err := db.Exec(ctx, "SELECT 1 FROM customers WHERE id = $1 FOR UPDATE", id); err != nil {
+		return fmt.Errorf("failed to lock customer: %w", err)
+	}
+	_, err := db.Exec(ctx, `
+		UPDATE customers
+		SET identification_status = CASE WHEN EXISTS(
+		        SELECT 1
+		        FROM identifications i
+		        JOIN wallets w ON w.id = i.wallet_id
+		        WHERE w.customer_id = customers.id AND i.status = 'APPROVED'
+		    ) THEN $1 ELSE $2 END,
+		    updated_at = $3
+		WHERE id = $4
+	`, models.IdentificationStatusIdentified, models.IdentificationStatusUnidentified, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update customer identification status: %w", err)
+	}
+	return nil
+}
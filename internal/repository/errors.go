@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrConflict is returned when a write violates a unique constraint. Services
+// map it to an error that names the duplicated value.
+var ErrConflict = errors.New("unique constraint violated")
+
+// ErrStatusChanged is returned by status transitions when the row no longer
+// has the expected status, i.e. another writer has changed it first.
+var ErrStatusChanged = errors.New("status changed concurrently")
+
+const uniqueViolation = "23505"
+
+// conflictError returns ErrConflict for unique violations and err otherwise.
+func conflictError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type HoldRepository interface {
+	Create(ctx context.Context, hold *models.Hold) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Hold, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Hold, error)
+	GetHeldAmount(ctx context.Context, walletID uuid.UUID, now time.Time) (models.Money, error)
+	Update(ctx context.Context, hold *models.Hold) error
+	ExpireBefore(ctx context.Context, now time.Time) (int64, error)
+}
+
+type PostgresHoldRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresHoldRepository(pool *pgxpool.Pool) *PostgresHoldRepository {
+	return &PostgresHoldRepository{pool: pool}
+}
+
+const holdColumns = "id, wallet_id, client_id, amount, captured_amount, description, status, capture_transaction_id, expires_at, created_at, updated_at"
+
+func scanHold(row pgx.Row) (*models.Hold, error) {
+	h := &models.Hold{}
+	err := row.Scan(&h.ID, &h.WalletID, &h.ClientID, &h.Amount, &h.CapturedAmount, &h.Description, &h.Status,
+		&h.CaptureTransactionID, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (r *PostgresHoldRepository) Create(ctx context.Context, h *models.Hold) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO holds (`+holdColumns+`)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		h.ID, h.WalletID, h.ClientID, h.Amount, h.CapturedAmount, h.Description, h.Status,
+		h.CaptureTransactionID, h.ExpiresAt, h.CreatedAt, h.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create hold: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresHoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	return scanHold(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+holdColumns+" FROM holds WHERE id = $1", id))
+}
+
+// GetByIDForUpdate locks the hold until the surrounding transaction ends.
+func (r *PostgresHoldRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	return scanHold(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+holdColumns+" FROM holds WHERE id = $1 FOR UPDATE", id))
+}
+
+// GetHeldAmount sums the wallet's holds that are still active at now. Holds
+// past their expiry are left out even if the expiry job has not marked them
+// yet, so they stop reserving money as soon as they expire.
+func (r *PostgresHoldRepository) GetHeldAmount(ctx context.Context, walletID uuid.UUID, now time.Time) (models.Money, error) {
+	var held models.Money
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM holds WHERE wallet_id = $1 AND status = 'ACTIVE' AND expires_at > $2",
+		walletID, now).Scan(&held)
+	return held, err
+}
+
+func (r *PostgresHoldRepository) Update(ctx context.Context, h *models.Hold) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE holds SET captured_amount = $1, status = $2, capture_transaction_id = $3, updated_at = $4 WHERE id = $5",
+		h.CapturedAmount, h.Status, h.CaptureTransactionID, h.UpdatedAt, h.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+	return nil
+}
+
+// ExpireBefore marks the active holds whose expiry has passed as EXPIRED and
+// returns how many there were.
+func (r *PostgresHoldRepository) ExpireBefore(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE holds SET status = 'EXPIRED', updated_at = $1 WHERE status = 'ACTIVE' AND expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
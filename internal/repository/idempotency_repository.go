@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, clientID uuid.UUID, key string) (*models.IdempotencyKey, error)
+	Lock(ctx context.Context, clientID uuid.UUID, key, requestHash string) (bool, error)
+	SaveResponse(ctx context.Context, clientID uuid.UUID, key string, statusCode int, body []byte, expiresAt time.Time) error
+	Delete(ctx context.Context, clientID uuid.UUID, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type PostgresIdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresIdempotencyRepository(pool *pgxpool.Pool) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{pool: pool}
+}
+
+// Reserve stores a new key and reports whether it was stored. An expired key
+// that has not been cleaned up yet, including one whose request never
+// finished, is taken over as if it did not exist.
+func (r *PostgresIdempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO idempotency_keys (client_id, key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (client_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    response_body = NULL,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < EXCLUDED.created_at
+	`, key.ClientID, key.Key, key.RequestHash, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresIdempotencyRepository) Get(ctx context.Context, clientID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	k := &models.IdempotencyKey{}
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT client_id, key, request_hash, status_code, response_body, created_at, expires_at FROM idempotency_keys WHERE client_id = $1 AND key = $2",
+		clientID, key).Scan(&k.ClientID, &k.Key, &k.RequestHash, &k.StatusCode, &k.ResponseBody, &k.CreatedAt, &k.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Lock locks a key reserved for a request in progress until the end of the
+// transaction, so that a retry can't take it over, and reports whether the
+// key is still reserved for the request.
+func (r *PostgresIdempotencyRepository) Lock(ctx context.Context, clientID uuid.UUID, key, requestHash string) (bool, error) {
+	var one int
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT 1 FROM idempotency_keys WHERE client_id = $1 AND key = $2 AND request_hash = $3 AND status_code IS NULL FOR UPDATE",
+		clientID, key, requestHash).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveResponse stores the response and keeps the key until expiresAt.
+func (r *PostgresIdempotencyRepository) SaveResponse(ctx context.Context, clientID uuid.UUID, key string, statusCode int, body []byte, expiresAt time.Time) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE idempotency_keys SET status_code = $1, response_body = $2, expires_at = $3 WHERE client_id = $4 AND key = $5",
+		statusCode, body, expiresAt, clientID, key)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) Delete(ctx context.Context, clientID uuid.UUID, key string) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM idempotency_keys WHERE client_id = $1 AND key = $2", clientID, key)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdentificationRepository interface {
+	Create(ctx context.Context, identification *models.Identification) error
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Identification, error)
+	GetLatestByWalletID(ctx context.Context, walletID uuid.UUID) (*models.Identification, error)
+	GetApprovedByWalletID(ctx context.Context, walletID uuid.UUID) (*models.Identification, error)
+	ListByStatus(ctx context.Context, status models.IdentificationState, limit, offset int) ([]*models.Identification, error)
+	GetApprovedExpiringBefore(ctx context.Context, date time.Time) ([]*models.Identification, error)
+	Update(ctx context.Context, identification *models.Identification) error
+}
+
+type PostgresIdentificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresIdentificationRepository(pool *pgxpool.Pool) *PostgresIdentificationRepository {
+	return &PostgresIdentificationRepository{pool: pool}
+}
+
+const identificationColumns = "id, wallet_id, document_type, document_number, document_expires_at, status, rejection_reason, submitted_by, reviewed_by, reviewed_at, created_at, updated_at"
+
+func scanIdentification(row pgx.Row) (*models.Identification, error) {
+	i := &models.Identification{}
+	err := row.Scan(&i.ID, &i.WalletID, &i.DocumentType, &i.DocumentNumber, &i.DocumentExpiresAt, &i.Status, &i.RejectionReason,
+		&i.SubmittedBy, &i.ReviewedBy, &i.ReviewedAt, &i.CreatedAt, &i.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Create fails with ErrConflict if the wallet already has a pending identification.
+func (r *PostgresIdentificationRepository) Create(ctx context.Context, i *models.Identification) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO identifications (`+identificationColumns+`)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		i.ID, i.WalletID, i.DocumentType, i.DocumentNumber, i.DocumentExpiresAt, i.Status, i.RejectionReason,
+		i.SubmittedBy, i.ReviewedBy, i.ReviewedAt, i.CreatedAt, i.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create identification: %w", conflictError(err))
+	}
+	return nil
+}
+
+// GetByIDForUpdate locks the identification until the surrounding transaction ends.
+func (r *PostgresIdentificationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Identification, error) {
+	return scanIdentification(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+identificationColumns+" FROM identifications WHERE id = $1 FOR UPDATE", id))
+}
+
+func (r *PostgresIdentificationRepository) GetLatestByWalletID(ctx context.Context, walletID uuid.UUID) (*models.Identification, error) {
+	return scanIdentification(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+identificationColumns+" FROM identifications WHERE wallet_id = $1 ORDER BY created_at DESC, id LIMIT 1", walletID))
+}
+
+func (r *PostgresIdentificationRepository) GetApprovedByWalletID(ctx context.Context, walletID uuid.UUID) (*models.Identification, error) {
+	return scanIdentification(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+identificationColumns+" FROM identifications WHERE wallet_id = $1 AND status = 'APPROVED'", walletID))
+}
+
+// ListByStatus returns one page of identifications in the status, oldest first.
+func (r *PostgresIdentificationRepository) ListByStatus(ctx context.Context, status models.IdentificationState, limit, offset int) ([]*models.Identification, error) {
+	return r.query(ctx,
+		"SELECT "+identificationColumns+" FROM identifications WHERE status = $1 ORDER BY created_at, id LIMIT $2 OFFSET $3",
+		status, limit, offset)
+}
+
+// GetApprovedExpiringBefore returns the approved identifications whose
+// document expired before date.
+func (r *PostgresIdentificationRepository) GetApprovedExpiringBefore(ctx context.Context, date time.Time) ([]*models.Identification, error) {
+	return r.query(ctx,
+		"SELECT "+identificationColumns+" FROM identifications WHERE status = 'APPROVED' AND document_expires_at < $1 ORDER BY document_expires_at, id",
+		date)
+}
+
+func (r *PostgresIdentificationRepository) query(ctx context.Context, sql string, args ...any) ([]*models.Identification, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identifications []*models.Identification
+	for rows.Next() {
+		i, err := scanIdentification(rows)
+		if err != nil {
+			return nil, err
+		}
+		identifications = append(identifications, i)
+	}
+	return identifications, rows.Err()
+}
+
+func (r *PostgresIdentificationRepository) Update(ctx context.Context, i *models.Identification) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE identifications
+         SET status = $1, rejection_reason = $2, reviewed_by = $3, reviewed_at = $4, updated_at = $5
+         WHERE id = $6`,
+		i.Status, i.RejectionReason, i.ReviewedBy, i.ReviewedAt, i.UpdatedAt, i.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update identification: %w", err)
+	}
+	return nil
+}
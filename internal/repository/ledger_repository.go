@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LedgerRepository interface {
+	GetSystemAccount(ctx context.Context, code, currency string) (*models.LedgerAccount, error)
+	CreateSystemAccounts(ctx context.Context, currency string) error
+	CreateJournalEntry(ctx context.Context, entry *models.JournalEntry) error
+	GetAccountBalance(ctx context.Context, accountID uuid.UUID) (models.Money, error)
+	FindWalletBalanceMismatches(ctx context.Context) ([]*models.BalanceMismatch, error)
+	GetTotalBalance(ctx context.Context) (models.Money, error)
+}
+
+type PostgresLedgerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresLedgerRepository(pool *pgxpool.Pool) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{pool: pool}
+}
+
+func (r *PostgresLedgerRepository) GetSystemAccount(ctx context.Context, code, currency string) (*models.LedgerAccount, error) {
+	account := &models.LedgerAccount{}
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT id, type, code, wallet_id, currency, created_at FROM ledger_accounts WHERE type = $1 AND code = $2 AND currency = $3",
+		models.LedgerAccountTypeSystem, code, currency).
+		Scan(&account.ID, &account.Type, &account.Code, &account.WalletID, &account.Currency, &account.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system account %s/%s: %w", code, currency, err)
+	}
+	return account, nil
+}
+
+// CreateSystemAccounts creates the system accounts of the currency that do not
+// exist yet.
+func (r *PostgresLedgerRepository) CreateSystemAccounts(ctx context.Context, currency string) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO ledger_accounts (id, type, code, currency)
+		SELECT gen_random_uuid(), $1, code, $2
+		FROM unnest($3::text[]) AS code
+		ON CONFLICT (code, currency) DO NOTHING
+	`, models.LedgerAccountTypeSystem, currency, models.SystemAccountCodes)
+	if err != nil {
+		return fmt.Errorf("failed to create system accounts for %s: %w", currency, err)
+	}
+	return nil
+}
+
+// CreateJournalEntry stores the entry with its postings. The database rejects
+// unbalanced entries at commit.
+func (r *PostgresLedgerRepository) CreateJournalEntry(ctx context.Context, entry *models.JournalEntry) error {
+	db := conn(ctx, r.pool)
+
+	_, err := db.Exec(ctx,
+		"INSERT INTO journal_entries (id, description, reference_id, created_at) VALUES ($1, $2, $3, $4)",
+		entry.ID, entry.Description, entry.ReferenceID, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	for _, posting := range entry.Postings {
+		_, err := db.Exec(ctx,
+			"INSERT INTO postings (id, journal_entry_id, account_id, amount, created_at) VALUES ($1, $2, $3, $4, $5)",
+			posting.ID, posting.JournalEntryID, posting.AccountID, posting.Amount, posting.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresLedgerRepository) GetAccountBalance(ctx context.Context, accountID uuid.UUID) (models.Money, error) {
+	var balance models.Money
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM postings WHERE account_id = $1",
+		accountID).Scan(&balance)
+	return balance, err
+}
+
+func (r *PostgresLedgerRepository) FindWalletBalanceMismatches(ctx context.Context) ([]*models.BalanceMismatch, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT w.id, w.balance, COALESCE(SUM(p.amount), 0)
+		FROM wallets w
+		LEFT JOIN postings p ON p.account_id = w.id
+		GROUP BY w.id, w.balance
+		HAVING w.balance <> COALESCE(SUM(p.amount), 0)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []*models.BalanceMismatch
+	for rows.Next() {
+		mismatch := &models.BalanceMismatch{}
+		if err := rows.Scan(&mismatch.WalletID, &mismatch.Balance, &mismatch.LedgerBalance); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return mismatches, rows.Err()
+}
+
+// GetTotalBalance returns the sum of all postings, which is zero for a consistent ledger.
+func (r *PostgresLedgerRepository) GetTotalBalance(ctx context.Context) (models.Money, error) {
+	var total models.Money
+	err := conn(ctx, r.pool).QueryRow(ctx, "SELECT COALESCE(SUM(amount), 0) FROM postings").Scan(&total)
+	return total, err
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NonceRepository interface {
+	Use(ctx context.Context, clientID uuid.UUID, nonce string, now, expiresAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type PostgresNonceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresNonceRepository(pool *pgxpool.Pool) *PostgresNonceRepository {
+	return &PostgresNonceRepository{pool: pool}
+}
+
+// Use stores the nonce and reports whether it was stored, i.e. whether it had
+// not been seen before. An expired nonce that has not been cleaned up yet is
+// treated as unseen.
+func (r *PostgresNonceRepository) Use(ctx context.Context, clientID uuid.UUID, nonce string, now, expiresAt time.Time) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO request_nonces (client_id, nonce, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (client_id, nonce) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at
+		WHERE request_nonces.expires_at < $4
+	`, clientID, nonce, expiresAt, now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresNonceRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, "DELETE FROM request_nonces WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
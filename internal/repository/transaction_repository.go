@@ -2,20 +2,34 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mabduqayum/ewallet/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *models.Transaction) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	GetByExternalRef(ctx context.Context, clientID uuid.UUID, externalRef string) (*models.Transaction, error)
+	GetByTransferID(ctx context.Context, transferID uuid.UUID) ([]*models.Transaction, error)
 	GetByWalletID(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error)
-	GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error)
+	List(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error)
+	GetStats(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]*models.TransactionStats, error)
+	GetSettledSum(ctx context.Context, walletID uuid.UUID, types []models.TransactionType, from, to time.Time) (models.Money, error)
+	UpdateReversedAmount(ctx context.Context, transaction *models.Transaction) error
+	TransitionStatus(ctx context.Context, transaction *models.Transaction, from models.TransactionStatus) error
 }
 
+const transactionColumns = "id, wallet_id, type, amount, description, transfer_id, reversal_of, reversed_amount, " +
+	"client_id, external_ref, status, status_changed_at, failure_reason, created_at, updated_at"
+
 type PostgresTransactionRepository struct {
 	pool *pgxpool.Pool
 }
@@ -24,51 +38,193 @@ func NewPostgresTransactionRepository(pool *pgxpool.Pool) *PostgresTransactionRe
 	return &PostgresTransactionRepository{pool: pool}
 }
 
-func (r *PostgresTransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
-	_, err := r.pool.Exec(ctx,
-		"INSERT INTO transactions (id, wallet_id, type, amount, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
-		transaction.ID, transaction.WalletID, transaction.Type, transaction.Amount, transaction.Description, transaction.CreatedAt, transaction.UpdatedAt)
-	return err
-}
-
-func (r *PostgresTransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
-	transaction := &models.Transaction{}
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, wallet_id, type, amount, description, created_at, updated_at FROM transactions WHERE id = $1",
-		id).Scan(&transaction.ID, &transaction.WalletID, &transaction.Type, &transaction.Amount, &transaction.Description, &transaction.CreatedAt, &transaction.UpdatedAt)
+func scanTransaction(row pgx.Row) (*models.Transaction, error) {
+	t := &models.Transaction{}
+	err := row.Scan(&t.ID, &t.WalletID, &t.Type, &t.Amount, &t.Description, &t.TransferID, &t.ReversalOf, &t.ReversedAmount,
+		&t.ClientID, &t.ExternalRef, &t.Status, &t.StatusChangedAt, &t.FailureReason, &t.CreatedAt, &t.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
-	return transaction, nil
+	return t, nil
+}
+
+// Create fails with ErrConflict if the client has already used the external
+// reference.
+func (r *PostgresTransactionRepository) Create(ctx context.Context, t *models.Transaction) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO transactions ("+transactionColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)",
+		t.ID, t.WalletID, t.Type, t.Amount, t.Description, t.TransferID, t.ReversalOf, t.ReversedAmount,
+		t.ClientID, t.ExternalRef, t.Status, t.StatusChangedAt, t.FailureReason, t.CreatedAt, t.UpdatedAt)
+	return conflictError(err)
+}
+
+func (r *PostgresTransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return scanTransaction(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+transactionColumns+" FROM transactions WHERE id = $1", id))
+}
+
+// GetByIDForUpdate locks the transaction until the surrounding transaction ends.
+func (r *PostgresTransactionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return scanTransaction(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+transactionColumns+" FROM transactions WHERE id = $1 FOR UPDATE", id))
+}
+
+// GetByExternalRef returns the transaction the client initiated with its own
+// reference.
+func (r *PostgresTransactionRepository) GetByExternalRef(ctx context.Context, clientID uuid.UUID, externalRef string) (*models.Transaction, error) {
+	return scanTransaction(conn(ctx, r.pool).QueryRow(ctx,
+		"SELECT "+transactionColumns+" FROM transactions WHERE client_id = $1 AND external_ref = $2", clientID, externalRef))
+}
+
+// GetByTransferID returns both legs of a transfer.
+func (r *PostgresTransactionRepository) GetByTransferID(ctx context.Context, transferID uuid.UUID) ([]*models.Transaction, error) {
+	return r.query(ctx, "SELECT "+transactionColumns+" FROM transactions WHERE transfer_id = $1 ORDER BY type", transferID)
 }
 
 func (r *PostgresTransactionRepository) GetByWalletID(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
-	rows, err := r.pool.Query(ctx,
-		"SELECT id, wallet_id, type, amount, description, created_at, updated_at FROM transactions WHERE wallet_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+	return r.query(ctx,
+		"SELECT "+transactionColumns+" FROM transactions WHERE wallet_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
 		walletID, limit, offset)
+}
+
+// List returns up to filter.Limit transactions of a wallet, newest first,
+// starting after filter.After. Ordering by (created_at, id) keeps pages stable
+// when several transactions share a timestamp.
+func (r *PostgresTransactionRepository) List(ctx context.Context, filter models.TransactionFilter) ([]*models.Transaction, error) {
+	conditions := []string{"wallet_id = $1"}
+	args := []any{filter.WalletID}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, t := range filter.Types {
+			types[i] = string(t)
+		}
+		addCondition("type::text = ANY($%d)", types)
+	}
+	if filter.MinAmount != nil {
+		addCondition("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM transactions
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, transactionColumns, strings.Join(conditions, " AND "), len(args))
+
+	return r.query(ctx, query, args...)
+}
+
+// GetStats aggregates the wallet's transactions per type over [from, to).
+// Only transactions that have changed the balance count.
+func (r *PostgresTransactionRepository) GetStats(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]*models.TransactionStats, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT type, COUNT(*), SUM(amount), MIN(amount), MAX(amount), ROUND(AVG(amount), 2)
+		FROM transactions
+		WHERE wallet_id = $1
+		  AND status IN ('COMPLETED', 'REVERSED')
+		  AND created_at >= $2
+		  AND created_at < $3
+		GROUP BY type
+		ORDER BY type
+	`, walletID, from, to)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var transactions []*models.Transaction
+	var stats []*models.TransactionStats
 	for rows.Next() {
-		transaction := &models.Transaction{}
-		err := rows.Scan(&transaction.ID, &transaction.WalletID, &transaction.Type, &transaction.Amount, &transaction.Description, &transaction.CreatedAt, &transaction.UpdatedAt)
-		if err != nil {
+		s := &models.TransactionStats{}
+		if err := rows.Scan(&s.Type, &s.Count, &s.Sum, &s.Min, &s.Max, &s.Average); err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, transaction)
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetSettledSum adds up the wallet's transactions of the given types that
+// changed the balance in [from, to), counted when they settled rather than
+// when they were created, and less the part that has been reversed.
+func (r *PostgresTransactionRepository) GetSettledSum(ctx context.Context, walletID uuid.UUID, types []models.TransactionType, from, to time.Time) (models.Money, error) {
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
 	}
 
-	return transactions, nil
+	var sum models.Money
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount - reversed_amount), 0)
+		FROM transactions
+		WHERE wallet_id = $1
+		  AND type::text = ANY($2)
+		  AND status IN ('COMPLETED', 'REVERSED')
+		  AND status_changed_at >= $3
+		  AND status_changed_at < $4
+	`, walletID, typeNames, from, to).Scan(&sum)
+	return sum, err
+}
+
+func (r *PostgresTransactionRepository) UpdateReversedAmount(ctx context.Context, t *models.Transaction) error {
+	t.UpdatedAt = time.Now()
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE transactions SET reversed_amount = $1, updated_at = $2 WHERE id = $3",
+		t.ReversedAmount, t.UpdatedAt, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update reversed amount: %w", err)
+	}
+	return nil
+}
+
+// TransitionStatus saves the transaction's new status provided it still has
+// status from, and fails with ErrStatusChanged otherwise, so that of two
+// writers settling the same transaction only the first succeeds.
+func (r *PostgresTransactionRepository) TransitionStatus(ctx context.Context, t *models.Transaction, from models.TransactionStatus) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE transactions SET status = $1, status_changed_at = $2, failure_reason = $3, updated_at = $4
+         WHERE id = $5 AND status = $6`,
+		t.Status, t.StatusChangedAt, t.FailureReason, t.UpdatedAt, t.ID, from)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+	return nil
 }
 
-func (r *PostgresTransactionRepository) GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error) {
-	var count int
-	var sum float64
-	err := r.pool.QueryRow(ctx,
-		"SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM transactions WHERE wallet_id = $1 AND type = $2 AND created_at >= DATE_TRUNC('month', CURRENT_DATE)",
-		walletID, models.TransactionTypeTopUp).Scan(&count, &sum)
-	return count, sum, err
+func (r *PostgresTransactionRepository) query(ctx context.Context, sql string, args ...any) ([]*models.Transaction, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
 }
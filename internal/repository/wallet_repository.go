@@ -8,6 +8,7 @@ import (
 	"github.com/mabduqayum/ewallet/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,10 +16,21 @@ type WalletRepository interface {
 	Create(ctx context.Context, wallet models.Wallet) error
 	Exists(ctx context.Context, walletID uuid.UUID) (bool, error)
 	GetByID(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error)
-	Update(ctx context.Context, wallet *models.Wallet, amount float64) error
-	GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error)
+	GetByIDForUpdate(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error)
+	UpdateBalance(ctx context.Context, wallet *models.Wallet) error
+	UpdateType(ctx context.Context, wallet *models.Wallet) error
+	UpdateStatus(ctx context.Context, wallet *models.Wallet) error
+	AssignOwner(ctx context.Context, walletID, clientID uuid.UUID) (bool, error)
+	GetUnownedIDs(ctx context.Context) ([]uuid.UUID, error)
+	HasAccess(ctx context.Context, walletID, clientID uuid.UUID) (bool, error)
+	GrantAccess(ctx context.Context, walletID, clientID uuid.UUID) error
+	RevokeAccess(ctx context.Context, walletID, clientID uuid.UUID) (bool, error)
+	GetGrantedClientIDs(ctx context.Context, walletID uuid.UUID) ([]uuid.UUID, error)
+	GetAccessibleByCustomer(ctx context.Context, customerID, clientID uuid.UUID, currency string) ([]*models.Wallet, error)
 }
 
+const walletColumns = "id, client_id, type, status, balance, currency, external_ref, customer_id, created_at, updated_at"
+
 type PostgresWalletRepository struct {
 	pool *pgxpool.Pool
 }
@@ -27,19 +39,33 @@ func NewPostgresWalletRepository(pool *pgxpool.Pool) *PostgresWalletRepository {
 	return &PostgresWalletRepository{pool: pool}
 }
 
+func scanWallet(row pgx.Row) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	var clientID *uuid.UUID
+	err := row.Scan(&wallet.ID, &clientID, &wallet.Type, &wallet.Status, &wallet.Balance, &wallet.Currency, &wallet.ExternalRef, &wallet.CustomerID, &wallet.CreatedAt, &wallet.UpdatedAt)
+	if clientID != nil {
+		wallet.ClientID = *clientID
+	}
+	return wallet, err
+}
+
 func (r *PostgresWalletRepository) Create(ctx context.Context, wallet models.Wallet) error {
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO wallets (id, type, balance, currency, created_at, updated_at)
-         VALUES ($1, $2, $3, $4, $5, $6)`,
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO wallets (id, client_id, type, status, balance, currency, external_ref, customer_id, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
 		wallet.ID,
+		wallet.ClientID,
 		wallet.Type,
+		wallet.Status,
 		wallet.Balance,
 		wallet.Currency,
+		wallet.ExternalRef,
+		wallet.CustomerID,
 		wallet.CreatedAt,
 		wallet.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("failed to create wallet: %w", err)
+		return fmt.Errorf("failed to create wallet: %w", conflictError(err))
 	}
 
 	return nil
@@ -47,56 +73,169 @@ func (r *PostgresWalletRepository) Create(ctx context.Context, wallet models.Wal
 
 func (r *PostgresWalletRepository) Exists(ctx context.Context, walletID uuid.UUID) (bool, error) {
 	var exists bool
-	err := r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM wallets WHERE id = $1)", walletID).Scan(&exists)
+	err := conn(ctx, r.pool).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM wallets WHERE id = $1)", walletID).Scan(&exists)
 	return exists, err
 }
 
 func (r *PostgresWalletRepository) GetByID(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error) {
-	wallet := &models.Wallet{}
-	err := r.pool.QueryRow(ctx, "SELECT id, type, balance, currency, created_at, updated_at FROM wallets WHERE id = $1", walletID).
-		Scan(&wallet.ID, &wallet.Type, &wallet.Balance, &wallet.Currency, &wallet.CreatedAt, &wallet.UpdatedAt)
-	return wallet, err
+	return scanWallet(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+walletColumns+" FROM wallets WHERE id = $1", walletID))
 }
 
-func (r *PostgresWalletRepository) Update(ctx context.Context, wallet *models.Wallet, amount float64) error {
-	tx, err := r.pool.Begin(ctx)
+// GetByIDForUpdate locks the wallet row until the surrounding transaction ends.
+// It must be called within Transactor.WithinTransaction.
+func (r *PostgresWalletRepository) GetByIDForUpdate(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := scanWallet(conn(ctx, r.pool).QueryRow(ctx, "SELECT "+walletColumns+" FROM wallets WHERE id = $1 FOR UPDATE", walletID))
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to lock wallet: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	return wallet, nil
+}
 
-	_, err = tx.Exec(ctx,
+func (r *PostgresWalletRepository) UpdateBalance(ctx context.Context, wallet *models.Wallet) error {
+	wallet.UpdatedAt = time.Now()
+	_, err := conn(ctx, r.pool).Exec(ctx,
 		"UPDATE wallets SET balance = $1, updated_at = $2 WHERE id = $3",
-		wallet.Balance, time.Now(), wallet.ID)
+		wallet.Balance, wallet.UpdatedAt, wallet.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update wallet balance: %w", err)
 	}
+	return nil
+}
 
-	transaction := models.NewTransaction(wallet.ID, models.TransactionTypeTopUp, amount, "Top-up")
-	_, err = tx.Exec(ctx, `
-			INSERT INTO transactions (id, wallet_id, type, amount, description, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, transaction.ID, transaction.WalletID, transaction.Type, transaction.Amount, transaction.Description, transaction.CreatedAt, transaction.UpdatedAt)
+func (r *PostgresWalletRepository) UpdateType(ctx context.Context, wallet *models.Wallet) error {
+	wallet.UpdatedAt = time.Now()
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE wallets SET type = $1, updated_at = $2 WHERE id = $3",
+		wallet.Type, wallet.UpdatedAt, wallet.ID)
 	if err != nil {
-		return fmt.Errorf("failed to create transaction record: %w", err)
+		return fmt.Errorf("failed to update wallet type: %w", err)
 	}
+	return nil
+}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+func (r *PostgresWalletRepository) UpdateStatus(ctx context.Context, wallet *models.Wallet) error {
+	wallet.UpdatedAt = time.Now()
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE wallets SET status = $1, updated_at = $2 WHERE id = $3",
+		wallet.Status, wallet.UpdatedAt, wallet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update wallet status: %w", err)
 	}
+	return nil
+}
 
+// HasAccess reports whether the wallet exists and the client owns it or has
+// been granted access to it.
+func (r *PostgresWalletRepository) HasAccess(ctx context.Context, walletID, clientID uuid.UUID) (bool, error) {
+	var ok bool
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT EXISTS(
+             SELECT 1 FROM wallets w
+             WHERE w.id = $1
+               AND (w.client_id = $2
+                    OR EXISTS(SELECT 1 FROM wallet_access a WHERE a.wallet_id = w.id AND a.client_id = $2)))`,
+		walletID, clientID).Scan(&ok)
+	return ok, err
+}
+
+// AssignOwner makes the client the owner of a wallet that has none and
+// reports whether the wallet had none.
+func (r *PostgresWalletRepository) AssignOwner(ctx context.Context, walletID, clientID uuid.UUID) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		"UPDATE wallets SET client_id = $1, updated_at = $2 WHERE id = $3 AND client_id IS NULL",
+		clientID, time.Now(), walletID)
+	if err != nil {
+		return false, fmt.Errorf("failed to assign wallet owner: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetUnownedIDs returns the wallets that predate ownership and have not been
+// given an owner yet, oldest first.
+func (r *PostgresWalletRepository) GetUnownedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		"SELECT id FROM wallets WHERE client_id IS NULL ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var walletIDs []uuid.UUID
+	for rows.Next() {
+		var walletID uuid.UUID
+		if err := rows.Scan(&walletID); err != nil {
+			return nil, err
+		}
+		walletIDs = append(walletIDs, walletID)
+	}
+	return walletIDs, rows.Err()
+}
+
+// GrantAccess lets the client use the wallet. Granting access twice is a no-op.
+func (r *PostgresWalletRepository) GrantAccess(ctx context.Context, walletID, clientID uuid.UUID) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		"INSERT INTO wallet_access (wallet_id, client_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		walletID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to grant wallet access: %w", err)
+	}
 	return nil
 }
 
-func (r *PostgresWalletRepository) GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error) {
-	var count int
-	var sum float64
-	err := r.pool.QueryRow(ctx, `
-		SELECT COUNT(*), COALESCE(SUM(amount), 0)
-		FROM transactions
-		WHERE wallet_id = $1
-		  AND type = 'TOP_UP'
-		  AND created_at >= DATE_TRUNC('month', CURRENT_DATE)
-	`, walletID).Scan(&count, &sum)
-	return count, sum, err
+// RevokeAccess reports whether the client had been granted access.
+func (r *PostgresWalletRepository) RevokeAccess(ctx context.Context, walletID, clientID uuid.UUID) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx,
+		"DELETE FROM wallet_access WHERE wallet_id = $1 AND client_id = $2",
+		walletID, clientID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke wallet access: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresWalletRepository) GetGrantedClientIDs(ctx context.Context, walletID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		"SELECT client_id FROM wallet_access WHERE wallet_id = $1 ORDER BY granted_at, client_id", walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clientIDs []uuid.UUID
+	for rows.Next() {
+		var clientID uuid.UUID
+		if err := rows.Scan(&clientID); err != nil {
+			return nil, err
+		}
+		clientIDs = append(clientIDs, clientID)
+	}
+	return clientIDs, rows.Err()
+}
+
+// GetAccessibleByCustomer returns the customer's wallets that the client owns
+// or has been granted access to, oldest first. A non-empty currency restricts
+// the result to wallets in that currency.
+func (r *PostgresWalletRepository) GetAccessibleByCustomer(ctx context.Context, customerID, clientID uuid.UUID, currency string) ([]*models.Wallet, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT `+walletColumns+` FROM wallets w
+         WHERE w.customer_id = $1
+           AND ($3 = '' OR w.currency = $3)
+           AND (w.client_id = $2
+                OR EXISTS(SELECT 1 FROM wallet_access a WHERE a.wallet_id = w.id AND a.client_id = $2))
+         ORDER BY w.created_at, w.id`,
+		customerID, clientID, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, rows.Err()
 }
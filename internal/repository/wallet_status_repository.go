@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WalletStatusChangeRepository interface {
+	Create(ctx context.Context, change *models.WalletStatusChange) error
+	GetByWalletID(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error)
+}
+
+type PostgresWalletStatusChangeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresWalletStatusChangeRepository(pool *pgxpool.Pool) *PostgresWalletStatusChangeRepository {
+	return &PostgresWalletStatusChangeRepository{pool: pool}
+}
+
+func (r *PostgresWalletStatusChangeRepository) Create(ctx context.Context, c *models.WalletStatusChange) error {
+	_, err := conn(ctx, r.pool).Exec(ctx,
+		`INSERT INTO wallet_status_changes (id, wallet_id, from_status, to_status, reason, actor, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.ID, c.WalletID, c.FromStatus, c.ToStatus, c.Reason, c.Actor, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet status change: %w", err)
+	}
+	return nil
+}
+
+// GetByWalletID returns the wallet's status history, newest first.
+func (r *PostgresWalletStatusChangeRepository) GetByWalletID(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx,
+		`SELECT id, wallet_id, from_status, to_status, reason, actor, created_at
+         FROM wallet_status_changes WHERE wallet_id = $1 ORDER BY created_at DESC, id`,
+		walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.WalletStatusChange
+	for rows.Next() {
+		c := &models.WalletStatusChange{}
+		if err := rows.Scan(&c.ID, &c.WalletID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.Actor, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartBackgroundJobs starts the periodic maintenance jobs. They stop when ctx is cancelled.
+func (s *FiberServer) StartBackgroundJobs(ctx context.Context) {
+	go runPeriodically(ctx, "idempotency key cleanup", s.cfg.Idempotency.CleanupInterval, s.idempotencyService.DeleteExpiredKeys)
+	go runPeriodically(ctx, "request nonce cleanup", s.cfg.Auth.NonceCleanupInterval, s.nonceService.DeleteExpiredNonces)
+	go runPeriodically(ctx, "scheduled client reactivation", s.cfg.Clients.ReactivationInterval, s.clientService.ReactivateDueClients)
+	go runPeriodically(ctx, "identification expiry", s.cfg.Identification.ExpiryCheckInterval, s.identificationService.ExpireDocuments)
+	go runPeriodically(ctx, "hold expiry", s.cfg.Holds.ExpiryCheckInterval, s.holdService.ExpireHolds)
+}
+
+func runPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("Background job %q failed: %v", name, err)
+			}
+		}
+	}
+}
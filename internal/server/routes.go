@@ -8,6 +8,7 @@ import (
 
 	"github.com/mabduqayum/ewallet/internal/handlers"
 	"github.com/mabduqayum/ewallet/internal/middleware"
+	"github.com/mabduqayum/ewallet/internal/models"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -18,14 +19,74 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	s.app.Get("/health", s.healthHandler)
 	s.app.Get("/websocket", websocket.New(s.websocketHandler))
 
-	api := s.app.Group("/api/v1", middleware.AuthMiddleware(s.clientService))
+	api := s.app.Group("/api/v1", middleware.AuthMiddleware(s.clientService, s.nonceService))
 
 	wallet := api.Group("/wallet")
 	walletHandler := handlers.NewWalletHandler(s.walletService)
-	wallet.Post("/exists", walletHandler.CheckWalletExists)
-	wallet.Post("/top-up", walletHandler.TopUpWallet)
-	wallet.Post("/stats", walletHandler.GetMonthlyTopUpStats)
-	wallet.Post("/balance", walletHandler.GetBalance)
+	wallet.Post("/create", middleware.RequireScope(models.ScopeWalletCreate), middleware.IdempotencyMiddleware(s.idempotencyService), walletHandler.CreateWallet)
+	wallet.Post("/exists", middleware.RequireScope(models.ScopeWalletRead), walletHandler.CheckWalletExists)
+	wallet.Post("/top-up", middleware.RequireScope(models.ScopeWalletTopUp), middleware.IdempotencyMiddleware(s.idempotencyService), walletHandler.TopUpWallet)
+	wallet.Post("/withdraw", middleware.RequireScope(models.ScopeWalletWithdraw), middleware.IdempotencyMiddleware(s.idempotencyService), walletHandler.WithdrawWallet)
+	wallet.Post("/transfer", middleware.RequireScope(models.ScopeWalletTransfer), middleware.IdempotencyMiddleware(s.idempotencyService), walletHandler.Transfer)
+	wallet.Post("/balance", middleware.RequireScope(models.ScopeWalletRead), walletHandler.GetBalance)
+
+	byPhone := wallet.Group("/by-phone")
+	byPhone.Post("/exists", middleware.RequireScope(models.ScopeWalletRead), walletHandler.CheckWalletExistsByPhone)
+	byPhone.Post("/balance", middleware.RequireScope(models.ScopeWalletRead), walletHandler.GetBalanceByPhone)
+	byPhone.Post("/top-up", middleware.RequireScope(models.ScopeWalletTopUp), middleware.IdempotencyMiddleware(s.idempotencyService), walletHandler.TopUpWalletByPhone)
+
+	identificationHandler := handlers.NewIdentificationHandler(s.identificationService)
+	wallet.Post("/identification", middleware.RequireScope(models.ScopeWalletIdentify), middleware.IdempotencyMiddleware(s.idempotencyService), identificationHandler.Submit)
+	wallet.Post("/identification/status", middleware.RequireScope(models.ScopeWalletRead), identificationHandler.GetStatus)
+
+	holdHandler := handlers.NewHoldHandler(s.holdService)
+	wallet.Post("/hold", middleware.RequireScope(models.ScopeWalletHold), middleware.IdempotencyMiddleware(s.idempotencyService), holdHandler.Place)
+	wallet.Post("/hold/capture", middleware.RequireScope(models.ScopeWalletHold), middleware.IdempotencyMiddleware(s.idempotencyService), holdHandler.Capture)
+	wallet.Post("/hold/release", middleware.RequireScope(models.ScopeWalletHold), middleware.IdempotencyMiddleware(s.idempotencyService), holdHandler.Release)
+	wallet.Post("/hold/status", middleware.RequireScope(models.ScopeWalletHold), holdHandler.GetStatus)
+
+	transactionHandler := handlers.NewTransactionHandler(s.transactionService)
+	wallet.Post("/transactions", middleware.RequireScope(models.ScopeWalletRead), transactionHandler.ListTransactions)
+	wallet.Post("/stats", middleware.RequireScope(models.ScopeWalletRead), transactionHandler.GetStats)
+
+	transaction := api.Group("/transaction")
+	transaction.Post("/status", middleware.RequireScope(models.ScopeWalletRead), transactionHandler.GetStatus)
+
+	admin := s.app.Group("/admin/v1", middleware.AdminAuthMiddleware(s.cfg.Admin.Tokens))
+
+	adminHandler := handlers.NewAdminHandler(s.adminService)
+	clients := admin.Group("/clients")
+	clients.Post("/", adminHandler.CreateClient)
+	clients.Get("/", adminHandler.ListClients)
+	clients.Get("/:id", adminHandler.GetClient)
+	clients.Patch("/:id", adminHandler.RenameClient)
+	clients.Put("/:id/scopes", adminHandler.SetClientScopes)
+	clients.Put("/:id/signature-scheme", adminHandler.SetClientSignatureScheme)
+	clients.Post("/:id/activate", adminHandler.ActivateClient)
+	clients.Post("/:id/deactivate", adminHandler.DeactivateClient)
+	clients.Get("/:id/credentials", adminHandler.ListCredentials)
+	clients.Post("/:id/credentials/reset", adminHandler.ResetCredentials)
+	clients.Delete("/:id/credentials/:credentialID", adminHandler.RevokeCredential)
+	clients.Get("/:id/audit", adminHandler.GetAuditTrail)
+
+	wallets := admin.Group("/wallets")
+	wallets.Put("/:id/owner/:clientID", adminHandler.AssignWalletOwner)
+	wallets.Get("/:id/access", adminHandler.GetWalletAccess)
+	wallets.Put("/:id/access/:clientID", adminHandler.GrantWalletAccess)
+	wallets.Delete("/:id/access/:clientID", adminHandler.RevokeWalletAccess)
+	wallets.Get("/:id/audit", adminHandler.GetWalletAuditTrail)
+	wallets.Post("/:id/status", adminHandler.ChangeWalletStatus)
+	wallets.Get("/:id/status-history", adminHandler.GetWalletStatusHistory)
+
+	transactions := admin.Group("/transactions")
+	transactions.Post("/:id/reverse", adminHandler.ReverseTransaction)
+	transactions.Post("/:id/complete", adminHandler.CompleteTransaction)
+	transactions.Post("/:id/fail", adminHandler.FailTransaction)
+
+	identifications := admin.Group("/identifications")
+	identifications.Get("/", adminHandler.ListIdentifications)
+	identifications.Post("/:id/approve", adminHandler.ApproveIdentification)
+	identifications.Post("/:id/reject", adminHandler.RejectIdentification)
 }
 
 func (s *FiberServer) HelloWorldHandler(c *fiber.Ctx) error {
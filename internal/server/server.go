@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"log"
+
 	"github.com/mabduqayum/ewallet/internal/config"
 	"github.com/mabduqayum/ewallet/internal/database"
 	"github.com/mabduqayum/ewallet/internal/repository"
 	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -13,39 +18,115 @@ import (
 type FiberServer struct {
 	app *fiber.App
 	db  database.Service
-	cfg *config.ServerConfig
+	cfg *config.Config
 
-	walletService *services.WalletService
-	clientService *services.ClientService
+	walletService         *services.WalletService
+	transactionService    *services.TransactionService
+	clientService         *services.ClientService
+	idempotencyService    *services.IdempotencyService
+	nonceService          *services.NonceService
+	adminService          *services.AdminService
+	identificationService *services.IdentificationService
+	holdService           *services.HoldService
+	limitsPolicy          *services.LimitsPolicy
 }
 
-func New(cfg *config.ServerConfig, db database.Service) *FiberServer {
+func New(cfg *config.Config, db database.Service) (*FiberServer, error) {
+	transactor := repository.NewPostgresTransactor(db.GetPool())
+
+	ledgerRepo := repository.NewPostgresLedgerRepository(db.GetPool())
+	ledgerService := services.NewLedgerService(ledgerRepo)
+
 	walletRepo := repository.NewPostgresWalletRepository(db.GetPool())
-	walletService := services.NewWalletService(walletRepo)
+	transactionRepo := repository.NewPostgresTransactionRepository(db.GetPool())
+	limitsPolicy, err := services.NewLimitsPolicy(cfg.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	holdRepo := repository.NewPostgresHoldRepository(db.GetPool())
+	limitsEngine := services.NewLimitsEngine(limitsPolicy, transactionRepo, cfg.Wallet.Location)
+	customerService := services.NewCustomerService(repository.NewPostgresCustomerRepository(db.GetPool()), cfg.Customers.DefaultCountryCode)
+	walletService := services.NewWalletService(transactor, walletRepo, transactionRepo, ledgerService, limitsEngine, customerService,
+		repository.NewPostgresWalletStatusChangeRepository(db.GetPool()), holdRepo)
+	transactionService := services.NewTransactionService(transactionRepo, walletService, cfg.Wallet.Location)
 
 	clientRepo := repository.NewPostgresClientRepository(db.GetPool())
-	clientService := services.NewClientService(clientRepo)
+	clientSuspensionRepo := repository.NewPostgresClientSuspensionRepository(db.GetPool())
+	keyring, err := secrets.NewKeyring(cfg.Secrets.CurrentKeyID, cfg.Secrets.MasterKeys)
+	if err != nil {
+		return nil, err
+	}
+	clientCredentialRepo := repository.NewPostgresClientCredentialRepository(db.GetPool())
+	clientService := services.NewClientService(transactor, clientRepo, clientSuspensionRepo, clientCredentialRepo, keyring, cfg.Clients.CredentialGracePeriod)
+	// The server doesn't start while it can't encrypt legacy plaintext secrets.
+	encrypted, err := clientService.EncryptPlaintextSecrets(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt plaintext client secrets: %w", err)
+	}
+	if encrypted > 0 {
+		log.Printf("Encrypted %d plaintext client secrets", encrypted)
+	}
+
+	idempotencyRepo := repository.NewPostgresIdempotencyRepository(db.GetPool())
+	idempotencyService := services.NewIdempotencyService(transactor, idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.LockTTL)
+
+	nonceRepo := repository.NewPostgresNonceRepository(db.GetPool())
+	nonceService := services.NewNonceService(nonceRepo, cfg.Auth.ClockSkew)
+
+	auditService := services.NewAuditService(repository.NewPostgresAuditRepository(db.GetPool()))
+	identificationService := services.NewIdentificationService(transactor, repository.NewPostgresIdentificationRepository(db.GetPool()),
+		walletRepo, repository.NewPostgresCustomerRepository(db.GetPool()), walletService, auditService, cfg.Wallet.Location)
+	holdService := services.NewHoldService(transactor, holdRepo, walletService, cfg.Holds.TTL)
+	reversalService := services.NewReversalService(transactor, walletService)
+	adminService := services.NewAdminService(transactor, clientService, walletService, identificationService, reversalService, auditService)
+	if len(cfg.Admin.Tokens) == 0 {
+		log.Println("No admin tokens configured; the admin API rejects every request")
+	}
 
 	server := &FiberServer{
 		app: fiber.New(fiber.Config{
 			ServerHeader: "ewallet",
-			AppName:      "ewallet v" + cfg.Version,
+			AppName:      "ewallet v" + cfg.Server.Version,
 		}),
 
-		db:            db,
-		cfg:           cfg,
-		walletService: walletService,
-		clientService: clientService,
+		db:                    db,
+		cfg:                   cfg,
+		walletService:         walletService,
+		transactionService:    transactionService,
+		clientService:         clientService,
+		idempotencyService:    idempotencyService,
+		nonceService:          nonceService,
+		adminService:          adminService,
+		identificationService: identificationService,
+		holdService:           holdService,
+		limitsPolicy:          limitsPolicy,
 	}
 
 	// Add recover middleware
 	server.app.Use(recover.New())
 
-	return server
+	return server, nil
+}
+
+// WatchConfig reloads the wallet limits and currencies whenever the config
+// file changes. The timezone is shared by services built at startup, so a
+// change to it only takes effect after a restart.
+func (s *FiberServer) WatchConfig() {
+	config.WatchWalletConfig(func(wallet config.WalletConfig) {
+		if err := s.limitsPolicy.Reload(wallet); err != nil {
+			log.Printf("Keeping previous wallet limits: %v", err)
+			return
+		}
+		log.Println("Wallet limits reloaded")
+		if wallet.Location.String() != s.cfg.Wallet.Location.String() {
+			log.Printf("Wallet timezone changed from %s to %s; restart the server to apply it", s.cfg.Wallet.Location, wallet.Location)
+		}
+	})
 }
 
 func (s *FiberServer) Listen() error {
-	return s.app.Listen(s.cfg.Address())
+	return s.app.Listen(s.cfg.Server.Address())
 }
 
 func (s *FiberServer) Shutdown() error {
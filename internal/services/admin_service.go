@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// AdminService performs administrative changes to clients and wallets on
+// behalf of an admin and audits each of them in the same transaction as the
+// change.
+type AdminService struct {
+	transactor            repository.Transactor
+	clientService         *ClientService
+	walletService         *WalletService
+	identificationService *IdentificationService
+	reversalService       *ReversalService
+	auditService          *AuditService
+}
+
+func NewAdminService(transactor repository.Transactor, clientService *ClientService, walletService *WalletService,
+	identificationService *IdentificationService, reversalService *ReversalService, auditService *AuditService) *AdminService {
+	return &AdminService{
+		transactor:            transactor,
+		clientService:         clientService,
+		walletService:         walletService,
+		identificationService: identificationService,
+		reversalService:       reversalService,
+		auditService:          auditService,
+	}
+}
+
+// CreateClient returns the new client and its first credential, the only time
+// the secret is available.
+func (s *AdminService) CreateClient(ctx context.Context, actor, name string, scopes []models.Scope) (*models.Client, *models.ClientCredential, error) {
+	var client *models.Client
+	var credential *models.ClientCredential
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if client, credential, err = s.clientService.CreateClient(ctx, name, scopes); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientCreated, models.AuditTargetClient, client.ID,
+			map[string]any{"name": client.Name, "scopes": client.Scopes, "credential_id": credential.ID})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, credential, nil
+}
+
+func (s *AdminService) ListClients(ctx context.Context, limit, offset int) ([]*models.Client, int, error) {
+	return s.clientService.ListClients(ctx, limit, offset)
+}
+
+func (s *AdminService) GetClient(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	return s.clientService.GetClientByID(ctx, id)
+}
+
+func (s *AdminService) RenameClient(ctx context.Context, actor string, id uuid.UUID, name string) (*models.Client, error) {
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.clientService.GetClientByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if client, err = s.clientService.RenameClient(ctx, id, name); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientRenamed, models.AuditTargetClient, id,
+			map[string]any{"from": previous.Name, "to": client.Name})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *AdminService) SetClientScopes(ctx context.Context, actor string, id uuid.UUID, scopes []models.Scope) (*models.Client, error) {
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.clientService.GetClientByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if client, err = s.clientService.SetScopes(ctx, id, scopes); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientScopesChanged, models.AuditTargetClient, id,
+			map[string]any{"from": previous.Scopes, "to": client.Scopes})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// SetClientSignatureScheme switches how the client signs its requests, e.g.
+// to opt a partner in to HMAC-SHA256.
+func (s *AdminService) SetClientSignatureScheme(ctx context.Context, actor string, id uuid.UUID, scheme models.SignatureScheme) (*models.Client, error) {
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.clientService.GetClientByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if client, err = s.clientService.SetSignatureScheme(ctx, id, scheme); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientSchemeChanged, models.AuditTargetClient, id,
+			map[string]any{"from": previous.SignatureScheme, "to": client.SignatureScheme})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *AdminService) ActivateClient(ctx context.Context, actor string, id uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.clientService.ReactivateClient(ctx, id, actor); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientActivated, models.AuditTargetClient, id, nil)
+	})
+}
+
+func (s *AdminService) DeactivateClient(ctx context.Context, actor string, id uuid.UUID, reason string, reactivateAt *time.Time) (*models.ClientSuspension, error) {
+	var suspension *models.ClientSuspension
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if suspension, err = s.clientService.SuspendClient(ctx, id, reason, actor, reactivateAt); err != nil {
+			return err
+		}
+		details := map[string]any{"reason": suspension.Reason, "suspension_id": suspension.ID}
+		if reactivateAt != nil {
+			details["reactivate_at"] = *reactivateAt
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientDeactivated, models.AuditTargetClient, id, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suspension, nil
+}
+
+// ResetCredentials issues a new credential; see ClientService.IssueCredential.
+func (s *AdminService) ResetCredentials(ctx context.Context, actor string, id uuid.UUID, expiresAt *time.Time, revokePrevious bool) (*models.ClientCredential, error) {
+	var credential *models.ClientCredential
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if credential, err = s.clientService.IssueCredential(ctx, id, expiresAt, revokePrevious); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientCredentialIssued, models.AuditTargetClient, id,
+			map[string]any{"credential_id": credential.ID, "revoke_previous": revokePrevious})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *AdminService) ListCredentials(ctx context.Context, id uuid.UUID) ([]*models.ClientCredential, error) {
+	return s.clientService.ListCredentials(ctx, id)
+}
+
+func (s *AdminService) RevokeCredential(ctx context.Context, actor string, id, credentialID uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.clientService.RevokeCredential(ctx, id, credentialID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditClientCredentialRevoked, models.AuditTargetClient, id,
+			map[string]any{"credential_id": credentialID})
+	})
+}
+
+func (s *AdminService) GetAuditTrail(ctx context.Context, id uuid.UUID) ([]*models.AuditEntry, error) {
+	if _, err := s.clientService.GetClientByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.auditService.GetTrail(ctx, models.AuditTargetClient, id)
+}
+
+// GetWalletAccess returns the wallet and the clients granted access to it
+// besides its owner.
+func (s *AdminService) GetWalletAccess(ctx context.Context, walletID uuid.UUID) (*models.Wallet, []uuid.UUID, error) {
+	wallet, err := s.walletService.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientIDs, err := s.walletService.GetGrantedClientIDs(ctx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet, clientIDs, nil
+}
+
+// AssignWalletOwner gives a wallet that predates ownership its owner.
+func (s *AdminService) AssignWalletOwner(ctx context.Context, actor string, walletID, clientID uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.clientService.GetClientByID(ctx, clientID); err != nil {
+			return err
+		}
+		if err := s.walletService.AssignOwner(ctx, walletID, clientID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditWalletOwnerAssigned, models.AuditTargetWallet, walletID,
+			map[string]any{"client_id": clientID})
+	})
+}
+
+// AssignWalletOwners gives every listed wallet the same owner. Either all of
+// them are assigned or, if one fails, none is.
+func (s *AdminService) AssignWalletOwners(ctx context.Context, actor string, clientID uuid.UUID, walletIDs []uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, walletID := range walletIDs {
+			if err := s.AssignWalletOwner(ctx, actor, walletID, clientID); err != nil {
+				return fmt.Errorf("wallet %s: %w", walletID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *AdminService) GrantWalletAccess(ctx context.Context, actor string, walletID, clientID uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.clientService.GetClientByID(ctx, clientID); err != nil {
+			return err
+		}
+		if err := s.walletService.GrantAccess(ctx, walletID, clientID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditWalletAccessGranted, models.AuditTargetWallet, walletID,
+			map[string]any{"client_id": clientID})
+	})
+}
+
+func (s *AdminService) RevokeWalletAccess(ctx context.Context, actor string, walletID, clientID uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.walletService.RevokeAccess(ctx, walletID, clientID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditWalletAccessRevoked, models.AuditTargetWallet, walletID,
+			map[string]any{"client_id": clientID})
+	})
+}
+
+func (s *AdminService) GetWalletAuditTrail(ctx context.Context, walletID uuid.UUID) ([]*models.AuditEntry, error) {
+	if _, err := s.walletService.GetWalletByID(ctx, walletID); err != nil {
+		return nil, err
+	}
+	return s.auditService.GetTrail(ctx, models.AuditTargetWallet, walletID)
+}
+
+func (s *AdminService) ChangeWalletStatus(ctx context.Context, actor string, walletID uuid.UUID, status models.WalletStatus, reason string) (*models.WalletStatusChange, error) {
+	var change *models.WalletStatusChange
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if change, err = s.walletService.ChangeStatus(ctx, walletID, status, reason, actor); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditWalletStatusChanged, models.AuditTargetWallet, walletID,
+			map[string]any{"from": change.FromStatus, "to": change.ToStatus, "reason": change.Reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (s *AdminService) GetWalletStatusHistory(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error) {
+	return s.walletService.GetStatusHistory(ctx, walletID)
+}
+
+func (s *AdminService) ListIdentifications(ctx context.Context, status models.IdentificationState, limit, offset int) ([]*models.Identification, error) {
+	return s.identificationService.ListByStatus(ctx, status, limit, offset)
+}
+
+// ApproveIdentification is audited by IdentificationService.Approve.
+func (s *AdminService) ApproveIdentification(ctx context.Context, actor string, id uuid.UUID) (*models.Identification, error) {
+	return s.identificationService.Approve(ctx, actor, id)
+}
+
+// RejectIdentification is audited by IdentificationService.Reject.
+func (s *AdminService) RejectIdentification(ctx context.Context, actor string, id uuid.UUID, reason string) (*models.Identification, error) {
+	return s.identificationService.Reject(ctx, actor, id, reason)
+}
+
+// ReverseTransaction reverses amount of the transaction, or all of what is
+// left when amount is zero, and returns the compensating transactions.
+func (s *AdminService) ReverseTransaction(ctx context.Context, actor string, id uuid.UUID, amount models.Money, reason string) ([]*models.Transaction, error) {
+	var reversals []*models.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if reversals, err = s.reversalService.Reverse(ctx, id, amount, reason); err != nil {
+			return err
+		}
+		reversalIDs := make([]uuid.UUID, len(reversals))
+		for i, reversal := range reversals {
+			reversalIDs[i] = reversal.ID
+		}
+		return s.auditService.Record(ctx, actor, models.AuditTransactionReversed, models.AuditTargetTransaction, id,
+			map[string]any{"amount": reversals[0].Amount, "reason": reversals[0].Description, "reversal_ids": reversalIDs})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+// CompleteTransaction settles a pending transaction and applies its balance
+// effect.
+func (s *AdminService) CompleteTransaction(ctx context.Context, actor string, id uuid.UUID) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if transaction, err = s.walletService.CompleteTransaction(ctx, id); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditTransactionCompleted, models.AuditTargetTransaction, id, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *AdminService) FailTransaction(ctx context.Context, actor string, id uuid.UUID, reason string) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if transaction, err = s.walletService.FailTransaction(ctx, id, reason); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditTransactionFailed, models.AuditTargetTransaction, id,
+			map[string]any{"reason": *transaction.FailureReason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record stores an audit entry. Call it inside the transaction of the audited
+// change so that the change and its record are committed together.
+func (s *AuditService) Record(ctx context.Context, actor string, action models.AuditAction, targetType string, targetID uuid.UUID, details map[string]any) error {
+	return s.repo.Create(ctx, models.NewAuditEntry(actor, action, targetType, targetID, details))
+}
+
+func (s *AuditService) GetTrail(ctx context.Context, targetType string, targetID uuid.UUID) ([]*models.AuditEntry, error) {
+	return s.repo.GetByTarget(ctx, targetType, targetID)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCredentialNotFound      = errors.New("credential not found")
+	ErrInvalidCredentialExpiry = errors.New("credential expiry must be in the future")
+)
+
+// AuthenticateClient returns the client and the decrypted secrets of its
+// currently valid credentials, newest first, so the request signature can be
+// checked against each. It is meant for AuthMiddleware only.
+func (s *ClientService) AuthenticateClient(ctx context.Context, apiKey string) (*models.Client, []models.Secret, error) {
+	client, err := s.repo.GetByAPIKey(ctx, apiKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credentials, err := s.credentialRepo.GetValid(ctx, client.ID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientSecrets := make([]models.Secret, 0, len(credentials))
+	for _, credential := range credentials {
+		secret, err := s.decryptSecret(credential)
+		if err != nil {
+			return nil, nil, fmt.Errorf("credential %s: %w", credential.ID, err)
+		}
+		clientSecrets = append(clientSecrets, secret)
+	}
+	return client, clientSecrets, nil
+}
+
+// IssueCredential creates a new secret for the client, valid immediately. The
+// client's other credentials keep working for the grace period so the partner
+// can switch over without downtime, or stop working at once if
+// revokePrevious is set, e.g. after a leak. A nil expiresAt never expires.
+func (s *ClientService) IssueCredential(ctx context.Context, clientID uuid.UUID, expiresAt *time.Time, revokePrevious bool) (*models.ClientCredential, error) {
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, ErrInvalidCredentialExpiry
+	}
+
+	credential := models.NewClientCredential(clientID, now, expiresAt)
+	if err := s.encryptSecret(credential); err != nil {
+		return nil, err
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.lockClient(ctx, clientID); err != nil {
+			return err
+		}
+		if err := s.credentialRepo.Create(ctx, credential); err != nil {
+			return err
+		}
+		graceEnd := now.Add(s.gracePeriod)
+		if revokePrevious {
+			graceEnd = now
+		}
+		return s.credentialRepo.ExpireValid(ctx, clientID, credential.ID, graceEnd)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// ListCredentials returns all of the client's credentials, newest first,
+// without their secrets.
+func (s *ClientService) ListCredentials(ctx context.Context, clientID uuid.UUID) ([]*models.ClientCredential, error) {
+	if _, err := s.GetClientByID(ctx, clientID); err != nil {
+		return nil, err
+	}
+	return s.credentialRepo.GetByClientID(ctx, clientID)
+}
+
+// RevokeCredential stops the credential from working immediately.
+func (s *ClientService) RevokeCredential(ctx context.Context, clientID, credentialID uuid.UUID) error {
+	revoked, err := s.credentialRepo.Revoke(ctx, clientID, credentialID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+// ReencryptSecrets encrypts every credential secret that isn't encrypted with
+// the current master key, including legacy plaintext secrets, and returns how
+// many were re-encrypted. Run it after adding a new master key and making it
+// current; the old key can be removed once it has finished.
+func (s *ClientService) ReencryptSecrets(ctx context.Context) (int, error) {
+	credentials, err := s.credentialRepo.GetNotEncryptedWith(ctx, s.keyring.CurrentKeyID())
+	if err != nil {
+		return 0, err
+	}
+	return s.reencrypt(ctx, credentials)
+}
+
+// EncryptPlaintextSecrets encrypts the legacy plaintext secrets with the
+// current master key and returns how many it encrypted. The server runs it at
+// startup, so plaintext secrets don't outlive the first deploy that can
+// encrypt them.
+func (s *ClientService) EncryptPlaintextSecrets(ctx context.Context) (int, error) {
+	credentials, err := s.credentialRepo.GetPlaintext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.reencrypt(ctx, credentials)
+}
+
+func (s *ClientService) reencrypt(ctx context.Context, credentials []*models.ClientCredential) (int, error) {
+	for i, credential := range credentials {
+		var err error
+		if credential.Secret, err = s.decryptSecret(credential); err != nil {
+			return i, fmt.Errorf("credential %s: %w", credential.ID, err)
+		}
+		if err := s.encryptSecret(credential); err != nil {
+			return i, fmt.Errorf("credential %s: %w", credential.ID, err)
+		}
+		if err := s.credentialRepo.UpdateSecret(ctx, credential); err != nil {
+			return i, fmt.Errorf("credential %s: %w", credential.ID, err)
+		}
+	}
+	return len(credentials), nil
+}
+
+// encryptSecret sets the credential's EncryptedSecret from its plaintext
+// Secret. The ciphertext is bound to the owning client's ID.
+func (s *ClientService) encryptSecret(credential *models.ClientCredential) error {
+	ciphertext, keyID, err := s.keyring.Encrypt(string(credential.Secret), credential.ClientID[:])
+	if err != nil {
+		return err
+	}
+	credential.EncryptedSecret = ciphertext
+	credential.SecretKeyID = &keyID
+	return nil
+}
+
+func (s *ClientService) decryptSecret(credential *models.ClientCredential) (models.Secret, error) {
+	if credential.SecretKeyID == nil {
+		// Plaintext secrets are encrypted at startup, so one showing up here
+		// was written behind the server's back.
+		log.Printf("Credential %s of client %s holds a plaintext secret; run reencrypt-secrets", credential.ID, credential.ClientID)
+		return models.Secret(credential.EncryptedSecret), nil
+	}
+	secret, err := s.keyring.Decrypt(credential.EncryptedSecret, *credential.SecretKeyID, credential.ClientID[:])
+	if err != nil {
+		return "", err
+	}
+	return models.Secret(secret), nil
+}
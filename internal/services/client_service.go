@@ -2,32 +2,92 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+var (
+	ErrClientNotFound           = errors.New("client not found")
+	ErrClientAlreadySuspended   = errors.New("client is already suspended")
+	ErrClientNotSuspended       = errors.New("client is not suspended")
+	ErrSuspensionReasonRequired = errors.New("suspension reason is required")
+	ErrInvalidReactivationTime  = errors.New("scheduled reactivation must be in the future")
+	ErrInvalidSignatureScheme   = errors.New("signature scheme must be HMAC-SHA1 or HMAC-SHA256")
+	ErrClientNameRequired       = errors.New("client name is required")
+)
+
+// reactivatedBySchedule is recorded as the actor of scheduled reactivations.
+const reactivatedBySchedule = "system:scheduled-reactivation"
+
 type ClientService struct {
-	repo repository.ClientRepository
+	transactor     repository.Transactor
+	repo           repository.ClientRepository
+	suspensionRepo repository.ClientSuspensionRepository
+	credentialRepo repository.ClientCredentialRepository
+	keyring        *secrets.Keyring
+	// gracePeriod is how long the previous credentials keep working after a
+	// new one is issued.
+	gracePeriod time.Duration
 }
 
-func NewClientService(repo repository.ClientRepository) *ClientService {
-	return &ClientService{repo: repo}
+func NewClientService(transactor repository.Transactor, repo repository.ClientRepository, suspensionRepo repository.ClientSuspensionRepository,
+	credentialRepo repository.ClientCredentialRepository, keyring *secrets.Keyring, gracePeriod time.Duration) *ClientService {
+	return &ClientService{
+		transactor:     transactor,
+		repo:           repo,
+		suspensionRepo: suspensionRepo,
+		credentialRepo: credentialRepo,
+		keyring:        keyring,
+		gracePeriod:    gracePeriod,
+	}
 }
 
-func (s *ClientService) CreateClient(ctx context.Context, name string) (*models.Client, error) {
+// CreateClient stores a new client with its first credential. The returned
+// credential is the only place the plaintext secret is ever handed out. A nil
+// scopes grants every scope.
+func (s *ClientService) CreateClient(ctx context.Context, name string, scopes []models.Scope) (*models.Client, *models.ClientCredential, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil, ErrClientNameRequired
+	}
+
 	client := models.NewClient(name)
-	err := s.repo.Create(ctx, client)
+	if scopes != nil {
+		client.Scopes = scopes
+	}
+	credential := models.NewClientCredential(client.ID, client.CreatedAt, nil)
+	if err := s.encryptSecret(credential); err != nil {
+		return nil, nil, err
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, client); err != nil {
+			return err
+		}
+		return s.credentialRepo.Create(ctx, credential)
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return client, nil
+	return client, credential, nil
 }
 
 func (s *ClientService) GetClientByID(ctx context.Context, id uuid.UUID) (*models.Client, error) {
-	return s.repo.GetByID(ctx, id)
+	client, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrClientNotFound
+	}
+	return client, err
 }
 
 func (s *ClientService) GetClientByAPIKey(ctx context.Context, apiKey string) (*models.Client, error) {
@@ -38,6 +98,61 @@ func (s *ClientService) GetAllClients(ctx context.Context) ([]*models.Client, er
 	return s.repo.GetAll(ctx)
 }
 
+// ListClients returns one page of clients, oldest first, and the total number of clients.
+func (s *ClientService) ListClients(ctx context.Context, limit, offset int) ([]*models.Client, int, error) {
+	clients, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return clients, total, nil
+}
+
+func (s *ClientService) RenameClient(ctx context.Context, id uuid.UUID, name string) (*models.Client, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrClientNameRequired
+	}
+
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if client, err = s.lockClient(ctx, id); err != nil {
+			return err
+		}
+
+		client.Name = name
+		client.UpdatedAt = time.Now()
+		return s.repo.Update(ctx, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// SetScopes replaces the client's scopes.
+func (s *ClientService) SetScopes(ctx context.Context, id uuid.UUID, scopes []models.Scope) (*models.Client, error) {
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if client, err = s.lockClient(ctx, id); err != nil {
+			return err
+		}
+
+		client.Scopes = scopes
+		client.UpdatedAt = time.Now()
+		return s.repo.Update(ctx, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func (s *ClientService) UpdateClient(ctx context.Context, client *models.Client) error {
 	return s.repo.Update(ctx, client)
 }
@@ -45,3 +160,123 @@ func (s *ClientService) UpdateClient(ctx context.Context, client *models.Client)
 func (s *ClientService) DeleteClient(ctx context.Context, id uuid.UUID) error {
 	return s.repo.Delete(ctx, id)
 }
+
+// SetSignatureScheme switches how the client signs its requests, e.g. to opt
+// a partner in to HMAC-SHA256 once it is ready.
+func (s *ClientService) SetSignatureScheme(ctx context.Context, id uuid.UUID, scheme models.SignatureScheme) (*models.Client, error) {
+	if !scheme.Valid() {
+		return nil, ErrInvalidSignatureScheme
+	}
+
+	var client *models.Client
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if client, err = s.lockClient(ctx, id); err != nil {
+			return err
+		}
+
+		client.SignatureScheme = scheme
+		client.UpdatedAt = time.Now()
+		return s.repo.Update(ctx, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// SuspendClient deactivates the client and records why. A non-nil
+// reactivateAt schedules the client to be reactivated automatically.
+func (s *ClientService) SuspendClient(ctx context.Context, id uuid.UUID, reason, suspendedBy string, reactivateAt *time.Time) (*models.ClientSuspension, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrSuspensionReasonRequired
+	}
+	if reactivateAt != nil && !reactivateAt.After(time.Now()) {
+		return nil, ErrInvalidReactivationTime
+	}
+
+	suspension := models.NewClientSuspension(id, reason, suspendedBy, reactivateAt)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		client, err := s.lockClient(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !client.Active {
+			return ErrClientAlreadySuspended
+		}
+
+		client.Active = false
+		client.UpdatedAt = suspension.SuspendedAt
+		if err := s.repo.Update(ctx, client); err != nil {
+			return err
+		}
+		return s.suspensionRepo.Create(ctx, suspension)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suspension, nil
+}
+
+// ReactivateClient lifts the client's suspension. Clients deactivated without
+// a recorded suspension are reactivated as well.
+func (s *ClientService) ReactivateClient(ctx context.Context, id uuid.UUID, reactivatedBy string) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		client, err := s.lockClient(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		suspension, err := s.suspensionRepo.GetCurrent(ctx, id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if client.Active {
+				return ErrClientNotSuspended
+			}
+		case err != nil:
+			return err
+		default:
+			if err := s.suspensionRepo.Lift(ctx, suspension.ID, reactivatedBy, now); err != nil {
+				return err
+			}
+		}
+
+		client.Active = true
+		client.UpdatedAt = now
+		return s.repo.Update(ctx, client)
+	})
+}
+
+// GetClientSuspensions returns the client's suspension history, newest first.
+func (s *ClientService) GetClientSuspensions(ctx context.Context, id uuid.UUID) ([]*models.ClientSuspension, error) {
+	return s.suspensionRepo.GetByClientID(ctx, id)
+}
+
+// ReactivateDueClients reactivates the clients whose scheduled reactivation
+// time has passed.
+func (s *ClientService) ReactivateDueClients(ctx context.Context) error {
+	due, err := s.suspensionRepo.GetDueForReactivation(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, suspension := range due {
+		if err := s.ReactivateClient(ctx, suspension.ClientID, reactivatedBySchedule); err != nil {
+			errs = append(errs, fmt.Errorf("client %s: %w", suspension.ClientID, err))
+			continue
+		}
+		log.Printf("Reactivated client %s as scheduled", suspension.ClientID)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ClientService) lockClient(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	client, err := s.repo.GetByIDForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrClientNotFound
+	}
+	return client, err
+}
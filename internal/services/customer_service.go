@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrCustomerDetailsMismatch means the holder details contradict those
+	// another wallet of the same phone number was opened with.
+	ErrCustomerDetailsMismatch = errors.New("holder details differ from those on record for this phone number")
+)
+
+type CustomerService struct {
+	repo repository.CustomerRepository
+	// defaultCountryCode is assumed for phone numbers given without one.
+	defaultCountryCode string
+}
+
+func NewCustomerService(repo repository.CustomerRepository, defaultCountryCode string) *CustomerService {
+	return &CustomerService{repo: repo, defaultCountryCode: defaultCountryCode}
+}
+
+// NormalizePhone converts the phone number to E.164; see models.NormalizePhone.
+func (s *CustomerService) NormalizePhone(phone string) (string, error) {
+	return models.NormalizePhone(phone, s.defaultCountryCode)
+}
+
+// GetOrCreateCustomer returns the customer with the phone number, creating
+// one from details if there is none. Customers are shared by all clients, so
+// details that contradict the stored ones fail with
+// ErrCustomerDetailsMismatch, and details the customer lacks are added.
+func (s *CustomerService) GetOrCreateCustomer(ctx context.Context, details models.CustomerDetails) (*models.Customer, error) {
+	phone, err := s.NormalizePhone(details.Phone)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.repo.GetOrCreate(ctx, models.NewCustomer(phone, details.Name, details.DateOfBirth))
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.LacksDetails(details) {
+		if customer, err = s.repo.FillDetails(ctx, customer.ID, details.Name, details.DateOfBirth); err != nil {
+			return nil, err
+		}
+	}
+	// Checked after filling in, as a concurrent request may have filled in
+	// different details first.
+	if !customer.MatchesDetails(details) {
+		return nil, ErrCustomerDetailsMismatch
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomerByPhone(ctx context.Context, phone string) (*models.Customer, error) {
+	phone, err := s.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := s.repo.GetByPhone(ctx, phone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	return customer, err
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrHoldNotFound = errors.New("hold not found")
+
+// HoldService reserves money in wallets so partners can settle it later. A
+// hold reduces the wallet's available balance until it is captured, released
+// or expires after the configured TTL.
+type HoldService struct {
+	transactor repository.Transactor
+	repo       repository.HoldRepository
+	wallets    *WalletService
+	ttl        time.Duration
+}
+
+func NewHoldService(transactor repository.Transactor, repo repository.HoldRepository, wallets *WalletService, ttl time.Duration) *HoldService {
+	return &HoldService{
+		transactor: transactor,
+		repo:       repo,
+		wallets:    wallets,
+		ttl:        ttl,
+	}
+}
+
+// Place reserves amount of the wallet's available balance for the client. It
+// fails with models.ErrInsufficientFunds when the available balance does not
+// cover the amount.
+func (s *HoldService) Place(ctx context.Context, client *models.Client, walletID uuid.UUID, amount models.Money, description string) (*models.Hold, error) {
+	hold := models.NewHold(walletID, client.ID, amount, description, s.ttl)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		wallet, err := s.wallets.lockWallet(ctx, client, walletID)
+		if err != nil {
+			return err
+		}
+		if err := wallet.CanDebit(); err != nil {
+			return err
+		}
+		available, err := s.wallets.AvailableBalance(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		if amount > available {
+			return models.ErrInsufficientFunds
+		}
+		return s.repo.Create(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Capture debits amount of the hold from the wallet and releases the rest. A
+// zero amount captures the whole hold. The debit is recorded as a
+// HOLD_CAPTURE transaction against the partner float account.
+func (s *HoldService) Capture(ctx context.Context, client *models.Client, holdID uuid.UUID, amount models.Money) (*models.Hold, error) {
+	var hold *models.Hold
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		found, err := s.Get(ctx, client, holdID)
+		if err != nil {
+			return err
+		}
+		// Lock the wallet before the hold, in the same order as Place.
+		wallet, err := s.wallets.lockWallet(ctx, client, found.WalletID)
+		if err != nil {
+			return err
+		}
+		if hold, err = s.lock(ctx, holdID); err != nil {
+			return err
+		}
+
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if err := hold.Capture(amount, time.Now()); err != nil {
+			return err
+		}
+		// The hold kept the amount out of the available balance, so only the
+		// wallet's status can refuse the debit.
+		if err := wallet.Debit(amount); err != nil {
+			return err
+		}
+		if err := s.wallets.repo.UpdateBalance(ctx, wallet); err != nil {
+			return err
+		}
+
+		description := hold.Description
+		if description == "" {
+			description = "Hold capture"
+		}
+		transaction := models.NewTransaction(wallet.ID, models.TransactionTypeHoldCapture, amount, description)
+		if err := s.wallets.transactionRepo.Create(ctx, transaction); err != nil {
+			return err
+		}
+		if err := s.wallets.ledger.PostWithSystemAccount(ctx, models.SystemAccountPartnerFloat, wallet, -amount, description, transaction.ID); err != nil {
+			return err
+		}
+
+		hold.CaptureTransactionID = &transaction.ID
+		return s.repo.Update(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Release gives the whole hold back to the wallet's available balance.
+func (s *HoldService) Release(ctx context.Context, client *models.Client, holdID uuid.UUID) (*models.Hold, error) {
+	var hold *models.Hold
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.Get(ctx, client, holdID); err != nil {
+			return err
+		}
+		var err error
+		if hold, err = s.lock(ctx, holdID); err != nil {
+			return err
+		}
+		if err := hold.Release(time.Now()); err != nil {
+			return err
+		}
+		return s.repo.Update(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Get returns the hold if the client placed it, and ErrHoldNotFound
+// otherwise.
+func (s *HoldService) Get(ctx context.Context, client *models.Client, holdID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.repo.GetByID(ctx, holdID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hold.ClientID != client.ID {
+		return nil, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// ExpireHolds marks the holds past their expiry as EXPIRED. They stop
+// reducing the available balance at their expiry either way.
+func (s *HoldService) ExpireHolds(ctx context.Context) error {
+	expired, err := s.repo.ExpireBefore(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d holds", expired)
+	}
+	return nil
+}
+
+func (s *HoldService) lock(ctx context.Context, holdID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.repo.GetByIDForUpdate(ctx, holdID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	return hold, err
+}
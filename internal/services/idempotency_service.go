@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// errResponseNotStored rolls back a request whose response must not be
+// replayed.
+var errResponseNotStored = errors.New("response is not stored")
+
+type IdempotencyService struct {
+	transactor repository.Transactor
+	repo       repository.IdempotencyRepository
+	ttl        time.Duration
+	// lockTTL bounds how long a reserved key waits for its request to start,
+	// so a key whose request never ran, e.g. because the server crashed, can
+	// be retried well before ttl. A running request holds the key for as long
+	// as it takes.
+	lockTTL time.Duration
+}
+
+func NewIdempotencyService(transactor repository.Transactor, repo repository.IdempotencyRepository, ttl, lockTTL time.Duration) *IdempotencyService {
+	return &IdempotencyService{transactor: transactor, repo: repo, ttl: ttl, lockTTL: lockTTL}
+}
+
+// Begin reserves the key for a new request. It returns nil when the caller
+// should process the request, or the stored key when the request was already
+// completed and its response must be replayed.
+func (s *IdempotencyService) Begin(ctx context.Context, clientID uuid.UUID, key, requestHash string) (*models.IdempotencyKey, error) {
+	reserved, err := s.repo.Reserve(ctx, models.NewIdempotencyKey(clientID, key, requestHash, s.lockTTL))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	existing, err := s.repo.Get(ctx, clientID, key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released by a failed request between the two queries.
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return existing, nil
+}
+
+// Process runs the request reserved by Begin in one transaction with storing
+// its response, so the response is stored if and only if the request's
+// changes are committed. handle must make its changes with the context it is
+// given and return the response. The key stays locked while handle runs, so
+// a retry waits for it instead of running the request again, however long it
+// takes.
+//
+// When handle fails or returns a server error (5xx), its changes are rolled
+// back and the key is released so the request can be retried; only handle's
+// error is returned then. ErrIdempotencyKeyInProgress means the key was taken
+// over by a retry before the request started and handle did not run.
+func (s *IdempotencyService) Process(ctx context.Context, clientID uuid.UUID, key, requestHash string, handle func(ctx context.Context) (int, []byte, error)) error {
+	var handleErr error
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := s.repo.Lock(ctx, clientID, key, requestHash)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return ErrIdempotencyKeyInProgress
+		}
+
+		statusCode, body, err := handle(ctx)
+		if err != nil {
+			handleErr = err
+			return err
+		}
+		if statusCode >= 500 {
+			return errResponseNotStored
+		}
+		return s.repo.SaveResponse(ctx, clientID, key, statusCode, body, time.Now().Add(s.ttl))
+	})
+	if err == nil || errors.Is(err, ErrIdempotencyKeyInProgress) {
+		return err
+	}
+
+	if releaseErr := s.Release(ctx, clientID, key); releaseErr != nil {
+		log.Printf("Failed to release idempotency key %q: %v", key, releaseErr)
+	}
+	if handleErr != nil {
+		return handleErr
+	}
+	if errors.Is(err, errResponseNotStored) {
+		return nil
+	}
+	return fmt.Errorf("failed to store response: %w", err)
+}
+
+// Release forgets the key so the request can be retried, e.g. after a server error.
+func (s *IdempotencyService) Release(ctx context.Context, clientID uuid.UUID, key string) error {
+	return s.repo.Delete(ctx, clientID, key)
+}
+
+func (s *IdempotencyService) DeleteExpiredKeys(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired idempotency keys", deleted)
+	}
+	return nil
+}
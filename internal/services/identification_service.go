@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrIdentificationNotFound   = errors.New("identification not found")
+	ErrInvalidDocumentType      = errors.New("document type must be PASSPORT, NATIONAL_ID or DRIVERS_LICENSE")
+	ErrDocumentNumberRequired   = errors.New("document number is required")
+	ErrDocumentExpired          = errors.New("document has expired")
+	ErrIdentificationPending    = errors.New("wallet already has an identification awaiting review")
+	ErrIdentificationNotPending = errors.New("identification has already been reviewed")
+	ErrRejectionReasonRequired  = errors.New("rejection reason is required")
+)
+
+// expiredBySchedule is recorded as the actor of downgrades after a document expires.
+const expiredBySchedule = "system:identification-expiry"
+
+// IdentificationService reviews the identity documents of wallet holders.
+// Approval makes the wallet IDENTIFIED and the expiry of the approved document
+// makes it UNIDENTIFIED again; the wallet limits follow the type. Every step
+// is audited against the wallet in the same transaction.
+type IdentificationService struct {
+	transactor   repository.Transactor
+	repo         repository.IdentificationRepository
+	walletRepo   repository.WalletRepository
+	customerRepo repository.CustomerRepository
+	wallets      *WalletService
+	auditService *AuditService
+	// location decides on which day a document expires.
+	location *time.Location
+}
+
+func NewIdentificationService(transactor repository.Transactor, repo repository.IdentificationRepository, walletRepo repository.WalletRepository,
+	customerRepo repository.CustomerRepository, wallets *WalletService, auditService *AuditService, location *time.Location) *IdentificationService {
+	return &IdentificationService{
+		transactor:   transactor,
+		repo:         repo,
+		walletRepo:   walletRepo,
+		customerRepo: customerRepo,
+		wallets:      wallets,
+		auditService: auditService,
+		location:     location,
+	}
+}
+
+// Submit records the holder's document for review. expiresAt is the expiry
+// date of the document at midnight UTC.
+func (s *IdentificationService) Submit(ctx context.Context, client *models.Client, walletID uuid.UUID, documentType models.DocumentType,
+	documentNumber string, expiresAt time.Time) (*models.Identification, error) {
+	if !documentType.Valid() {
+		return nil, ErrInvalidDocumentType
+	}
+	documentNumber = strings.TrimSpace(documentNumber)
+	if documentNumber == "" {
+		return nil, ErrDocumentNumberRequired
+	}
+
+	actor := clientActor(client)
+	identification := models.NewIdentification(walletID, documentType, documentNumber, expiresAt, actor)
+	if identification.ExpiredOn(s.today()) {
+		return nil, ErrDocumentExpired
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.wallets.GetWallet(ctx, client, walletID); err != nil {
+			return err
+		}
+
+		err := s.repo.Create(ctx, identification)
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrIdentificationPending
+		}
+		if err != nil {
+			return err
+		}
+
+		// The document number is personal data and stays out of the audit log.
+		return s.auditService.Record(ctx, actor, models.AuditIdentificationSubmitted, models.AuditTargetWallet, walletID,
+			map[string]any{"identification_id": identification.ID, "document_type": documentType, "document_expires_at": expiresAt.Format(time.DateOnly)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identification, nil
+}
+
+// GetLatest returns the wallet's most recent identification.
+func (s *IdentificationService) GetLatest(ctx context.Context, client *models.Client, walletID uuid.UUID) (*models.Identification, error) {
+	if _, err := s.wallets.GetWallet(ctx, client, walletID); err != nil {
+		return nil, err
+	}
+	identification, err := s.repo.GetLatestByWalletID(ctx, walletID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIdentificationNotFound
+	}
+	return identification, err
+}
+
+// ListByStatus returns one page of identifications in the status, oldest first.
+func (s *IdentificationService) ListByStatus(ctx context.Context, status models.IdentificationState, limit, offset int) ([]*models.Identification, error) {
+	return s.repo.ListByStatus(ctx, status, limit, offset)
+}
+
+// Approve makes the wallet IDENTIFIED, together with its holder. An
+// identification approved earlier for the wallet is superseded.
+func (s *IdentificationService) Approve(ctx context.Context, actor string, id uuid.UUID) (*models.Identification, error) {
+	var identification *models.Identification
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if identification, err = s.lockPending(ctx, id); err != nil {
+			return err
+		}
+		if identification.ExpiredOn(s.today()) {
+			return ErrDocumentExpired
+		}
+
+		now := time.Now()
+		previous, err := s.repo.GetApprovedByWalletID(ctx, identification.WalletID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			previous.Status = models.IdentificationSuperseded
+			previous.UpdatedAt = now
+			if err := s.repo.Update(ctx, previous); err != nil {
+				return err
+			}
+		}
+
+		identification.Status = models.IdentificationApproved
+		identification.ReviewedBy = &actor
+		identification.ReviewedAt = &now
+		identification.UpdatedAt = now
+		if err := s.repo.Update(ctx, identification); err != nil {
+			return err
+		}
+
+		from, err := s.setWalletType(ctx, identification.WalletID, models.WalletTypeIdentified)
+		if err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditIdentificationApproved, models.AuditTargetWallet, identification.WalletID,
+			map[string]any{"identification_id": identification.ID, "from": from, "to": models.WalletTypeIdentified})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identification, nil
+}
+
+func (s *IdentificationService) Reject(ctx context.Context, actor string, id uuid.UUID, reason string) (*models.Identification, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+
+	var identification *models.Identification
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if identification, err = s.lockPending(ctx, id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		identification.Status = models.IdentificationRejected
+		identification.RejectionReason = &reason
+		identification.ReviewedBy = &actor
+		identification.ReviewedAt = &now
+		identification.UpdatedAt = now
+		if err := s.repo.Update(ctx, identification); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, actor, models.AuditIdentificationRejected, models.AuditTargetWallet, identification.WalletID,
+			map[string]any{"identification_id": identification.ID, "reason": reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identification, nil
+}
+
+// ExpireDocuments downgrades the wallets whose approved document has expired
+// to UNIDENTIFIED. Money above the lower limit stays in the wallet, but no
+// more can be credited until the holder is identified again.
+func (s *IdentificationService) ExpireDocuments(ctx context.Context) error {
+	expired, err := s.repo.GetApprovedExpiringBefore(ctx, s.today())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, identification := range expired {
+		if err := s.expire(ctx, identification.ID); err != nil {
+			errs = append(errs, fmt.Errorf("identification %s: %w", identification.ID, err))
+			continue
+		}
+		log.Printf("Downgraded wallet %s after its identification document expired", identification.WalletID)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *IdentificationService) expire(ctx context.Context, id uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		identification, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		// Re-check under the lock: a newer approval may have superseded it.
+		if identification.Status != models.IdentificationApproved || !identification.ExpiredOn(s.today()) {
+			return nil
+		}
+
+		identification.Status = models.IdentificationExpired
+		identification.UpdatedAt = time.Now()
+		if err := s.repo.Update(ctx, identification); err != nil {
+			return err
+		}
+
+		from, err := s.setWalletType(ctx, identification.WalletID, models.WalletTypeUnidentified)
+		if err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, expiredBySchedule, models.AuditIdentificationExpired, models.AuditTargetWallet, identification.WalletID,
+			map[string]any{"identification_id": identification.ID, "from": from, "to": models.WalletTypeUnidentified})
+	})
+}
+
+// setWalletType changes the type of the wallet, refreshes the identification
+// status of its holder and returns the previous type. The wallet row is locked
+// so the change is serialized with money movements checking its limits. Call
+// it after saving the identification's new status: a holder stays IDENTIFIED
+// while any of their wallets has an approved identification.
+func (s *IdentificationService) setWalletType(ctx context.Context, walletID uuid.UUID, walletType models.WalletType) (models.WalletType, error) {
+	wallet, err := s.walletRepo.GetByIDForUpdate(ctx, walletID)
+	if err != nil {
+		return "", err
+	}
+
+	from := wallet.Type
+	if from != walletType {
+		wallet.Type = walletType
+		if err := s.walletRepo.UpdateType(ctx, wallet); err != nil {
+			return "", err
+		}
+	}
+	if wallet.CustomerID != nil {
+		if err := s.customerRepo.RefreshIdentificationStatus(ctx, *wallet.CustomerID); err != nil {
+			return "", err
+		}
+	}
+	return from, nil
+}
+
+func (s *IdentificationService) lockPending(ctx context.Context, id uuid.UUID) (*models.Identification, error) {
+	identification, err := s.repo.GetByIDForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrIdentificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if identification.Status != models.IdentificationPending {
+		return nil, ErrIdentificationNotPending
+	}
+	return identification, nil
+}
+
+// today returns the current date in the configured location at midnight UTC,
+// the form dates are stored in.
+func (s *IdentificationService) today() time.Time {
+	y, m, d := time.Now().In(s.location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// clientActor names a partner in the audit log.
+func clientActor(client *models.Client) string {
+	return "client:" + client.ID.String()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// LedgerService records every balance change as a balanced journal entry so
+// wallet balances can be proven against their history.
+type LedgerService struct {
+	repo repository.LedgerRepository
+}
+
+func NewLedgerService(repo repository.LedgerRepository) *LedgerService {
+	return &LedgerService{repo: repo}
+}
+
+func (s *LedgerService) Post(ctx context.Context, entry *models.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreateJournalEntry(ctx, entry)
+}
+
+// EnsureSystemAccounts creates the system accounts of a currency unless they
+// exist. The migrations only created them for the currencies in use at the
+// time, so a currency added to the configuration later gets them here.
+func (s *LedgerService) EnsureSystemAccounts(ctx context.Context, currency string) error {
+	return s.repo.CreateSystemAccounts(ctx, currency)
+}
+
+// PostWithSystemAccount moves amount from the system account identified by
+// code to the wallet. A negative amount moves money back to the system account.
+func (s *LedgerService) PostWithSystemAccount(ctx context.Context, code string, wallet *models.Wallet, amount models.Money, description string, referenceID uuid.UUID) error {
+	account, err := s.repo.GetSystemAccount(ctx, code, wallet.Currency)
+	if err != nil {
+		return err
+	}
+
+	entry := models.NewJournalEntry(description, &referenceID).Move(account.ID, wallet.ID, amount)
+	return s.Post(ctx, entry)
+}
+
+// GetAccountBalance derives the balance of an account from its postings.
+func (s *LedgerService) GetAccountBalance(ctx context.Context, accountID uuid.UUID) (models.Money, error) {
+	return s.repo.GetAccountBalance(ctx, accountID)
+}
+
+// VerifyWalletBalances checks that every posting pair balances out and that
+// each wallet's cached balance equals the sum of its postings. It returns the
+// wallets that don't match.
+func (s *LedgerService) VerifyWalletBalances(ctx context.Context) ([]*models.BalanceMismatch, error) {
+	total, err := s.repo.GetTotalBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if total != 0 {
+		return nil, fmt.Errorf("%w: ledger total is %s", models.ErrUnbalancedEntry, total)
+	}
+
+	return s.repo.FindWalletBalanceMismatches(ctx)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+)
+
+// incomingTransactionTypes count towards a wallet's incoming turnover.
+var incomingTransactionTypes = []models.TransactionType{
+	models.TransactionTypeTopUp,
+	models.TransactionTypeTransferIn,
+}
+
+// LimitsEngine checks money entering a wallet against the maximum balance and
+// the regulatory turnover limits of the wallet's type: the size of a single
+// operation and the cumulative incoming amount of the current day and month.
+type LimitsEngine struct {
+	policy          *LimitsPolicy
+	transactionRepo repository.TransactionRepository
+	// location is the timezone that days and months are counted in.
+	location *time.Location
+}
+
+func NewLimitsEngine(policy *LimitsPolicy, transactionRepo repository.TransactionRepository, location *time.Location) *LimitsEngine {
+	return &LimitsEngine{policy: policy, transactionRepo: transactionRepo, location: location}
+}
+
+func (e *LimitsEngine) MaxBalance(wallet *models.Wallet) models.Money {
+	return e.policy.MaxBalance(wallet)
+}
+
+// CheckCredit returns a *models.LimitExceededError when crediting amount would
+// break one of the wallet's limits. The wallet row must be locked by the
+// caller's transaction so concurrent credits can't both pass the check.
+func (e *LimitsEngine) CheckCredit(ctx context.Context, wallet *models.Wallet, amount models.Money) error {
+	if err := e.CheckOperation(wallet, amount); err != nil {
+		return err
+	}
+	turnover := e.policy.TurnoverLimits(wallet)
+
+	now := time.Now()
+	checks := []struct {
+		kind   models.LimitKind
+		max    models.Money
+		period models.StatsPeriod
+	}{
+		{models.LimitDailyTurnover, turnover.Daily, models.StatsPeriodDay},
+		{models.LimitMonthlyTurnover, turnover.Monthly, models.StatsPeriodMonth},
+	}
+	for _, check := range checks {
+		if check.max <= 0 {
+			continue
+		}
+
+		used, err := e.incomingTurnover(ctx, wallet, check.period, now)
+		if err != nil {
+			return err
+		}
+		if used+amount > check.max {
+			return models.NewLimitExceededError(check.kind, check.max, used)
+		}
+	}
+
+	if maxBalance := e.policy.MaxBalance(wallet); wallet.Balance+amount > maxBalance {
+		return models.NewLimitExceededError(models.LimitMaxBalance, maxBalance, wallet.Balance)
+	}
+
+	return nil
+}
+
+// CheckOperation returns a *models.LimitExceededError when amount is larger
+// than a single operation into the wallet may be. Unlike CheckCredit it needs
+// no lock, so it can reject a credit before it is accepted for later
+// settlement.
+func (e *LimitsEngine) CheckOperation(wallet *models.Wallet, amount models.Money) error {
+	turnover := e.policy.TurnoverLimits(wallet)
+	if turnover.MaxOperation > 0 && amount > turnover.MaxOperation {
+		return models.NewLimitExceededError(models.LimitSingleOperation, turnover.MaxOperation, 0)
+	}
+	return nil
+}
+
+// incomingTurnover is the money that entered the wallet during the period.
+// Money counts on the day it was credited, which for a pending top-up is the
+// day it completed, and reversed money does not count.
+func (e *LimitsEngine) incomingTurnover(ctx context.Context, wallet *models.Wallet, period models.StatsPeriod, now time.Time) (models.Money, error) {
+	from, to, err := period.Bounds(now, e.location)
+	if err != nil {
+		return 0, err
+	}
+	return e.transactionRepo.GetSettledSum(ctx, wallet.ID, incomingTransactionTypes, from, to)
+}
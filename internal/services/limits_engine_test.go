@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// settledTransactionRepository returns fixed settled sums per type for any
+// interval.
+type settledTransactionRepository struct {
+	repository.TransactionRepository
+	settled map[models.TransactionType]models.Money
+}
+
+func (r *settledTransactionRepository) GetSettledSum(_ context.Context, _ uuid.UUID, types []models.TransactionType, _, _ time.Time) (models.Money, error) {
+	var sum models.Money
+	for _, t := range types {
+		sum += r.settled[t]
+	}
+	return sum, nil
+}
+
+func TestLimitsEngineCheckCredit(t *testing.T) {
+	policy, err := NewLimitsPolicy(config.WalletConfig{
+		UnidentifiedLimit: 10_000,
+		IdentifiedLimit:   100_000,
+		TurnoverLimits: map[string]config.TurnoverLimitsConfig{
+			"unidentified": {MaxOperation: 5_000, Daily: 8_000, Monthly: 30_000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy: %v", err)
+	}
+
+	repo := &settledTransactionRepository{settled: map[models.TransactionType]models.Money{
+		models.TransactionTypeTopUp:      models.NewMoney(4_000),
+		models.TransactionTypeTransferIn: models.NewMoney(1_000),
+		models.TransactionTypeWithdraw:   models.NewMoney(2_000),
+	}}
+	engine := NewLimitsEngine(policy, repo, time.UTC)
+	wallet := models.NewWallet(uuid.New(), models.WalletTypeUnidentified, "TJS")
+
+	tests := []struct {
+		amount    models.Money
+		limit     models.LimitKind
+		remaining string
+	}{
+		{models.NewMoney(5_001), models.LimitSingleOperation, "5000.00"},
+		{models.NewMoney(3_001), models.LimitDailyTurnover, "3000.00"},
+	}
+	for _, tt := range tests {
+		err := engine.CheckCredit(context.Background(), wallet, tt.amount)
+		var limitErr *models.LimitExceededError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("credit of %s: expected a limit error, got %v", tt.amount, err)
+		}
+		if limitErr.Limit != tt.limit || limitErr.Remaining.String() != tt.remaining {
+			t.Errorf("credit of %s: got %s with %s remaining; want %s with %s remaining",
+				tt.amount, limitErr.Limit, limitErr.Remaining, tt.limit, tt.remaining)
+		}
+		if !errors.Is(err, models.ErrBalanceLimitExceeded) {
+			t.Errorf("credit of %s: limit errors must match ErrBalanceLimitExceeded", tt.amount)
+		}
+	}
+
+	if err := engine.CheckCredit(context.Background(), wallet, models.NewMoney(3_000)); err != nil {
+		t.Errorf("credit within the limits failed: %v", err)
+	}
+
+	// The operation size alone doesn't depend on the turnover so far.
+	var limitErr *models.LimitExceededError
+	if err := engine.CheckOperation(wallet, models.NewMoney(5_001)); !errors.As(err, &limitErr) || limitErr.Limit != models.LimitSingleOperation {
+		t.Errorf("expected a single-operation limit error, got %v", err)
+	}
+	if err := engine.CheckOperation(wallet, models.NewMoney(5_000)); err != nil {
+		t.Errorf("operation within the limit failed: %v", err)
+	}
+
+	identified := models.NewWallet(uuid.New(), models.WalletTypeIdentified, "TJS")
+	if err := engine.CheckCredit(context.Background(), identified, models.NewMoney(50_000)); err != nil {
+		t.Errorf("identified wallets have no turnover limits: %v", err)
+	}
+}
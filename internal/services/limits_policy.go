@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/models"
+)
+
+// LimitsPolicy decides the currencies wallets may be opened in and the
+// maximum balance and turnover limits of a wallet from the wallet
+// configuration. Balance limits can be set per wallet type and
+// overridden per currency; turnover limits are set per wallet type. The
+// policy is safe for concurrent use and can be reloaded while the server is
+// running.
+type LimitsPolicy struct {
+	limits atomic.Pointer[walletLimits]
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type walletLimits struct {
+	currencies map[string]bool
+	byType     map[models.WalletType]models.Money
+	byCurrency map[string]map[models.WalletType]models.Money
+	turnover   map[models.WalletType]models.TurnoverLimits
+}
+
+func NewLimitsPolicy(cfg config.WalletConfig) (*LimitsPolicy, error) {
+	p := &LimitsPolicy{}
+	if err := p.Reload(cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload replaces the limits. On error the previous limits stay in effect.
+func (p *LimitsPolicy) Reload(cfg config.WalletConfig) error {
+	limits := &walletLimits{
+		currencies: make(map[string]bool),
+		byType:     make(map[models.WalletType]models.Money),
+		byCurrency: make(map[string]map[models.WalletType]models.Money),
+		turnover:   make(map[models.WalletType]models.TurnoverLimits),
+	}
+
+	for _, currency := range cfg.Currencies {
+		currency = strings.ToUpper(currency)
+		if !currencyPattern.MatchString(currency) {
+			return fmt.Errorf("invalid currency code %q", currency)
+		}
+		limits.currencies[currency] = true
+	}
+
+	var err error
+	if limits.byType[models.WalletTypeUnidentified], err = configBalanceLimit(cfg.UnidentifiedLimit); err != nil {
+		return fmt.Errorf("invalid unidentified wallet limit: %w", err)
+	}
+	if limits.byType[models.WalletTypeIdentified], err = configBalanceLimit(cfg.IdentifiedLimit); err != nil {
+		return fmt.Errorf("invalid identified wallet limit: %w", err)
+	}
+
+	// Viper lower-cases map keys, so currencies and wallet types are normalized here.
+	for currency, byType := range cfg.CurrencyLimits {
+		currency = strings.ToUpper(currency)
+		limits.byCurrency[currency] = make(map[models.WalletType]models.Money)
+		for walletType, limit := range byType {
+			wt, err := configWalletType(walletType)
+			if err != nil {
+				return err
+			}
+			if limits.byCurrency[currency][wt], err = configBalanceLimit(limit); err != nil {
+				return fmt.Errorf("invalid %s %s wallet limit: %w", currency, wt, err)
+			}
+		}
+	}
+
+	for walletType, turnover := range cfg.TurnoverLimits {
+		wt, err := configWalletType(walletType)
+		if err != nil {
+			return err
+		}
+
+		var t models.TurnoverLimits
+		if t.MaxOperation, err = configMoney(turnover.MaxOperation); err != nil {
+			return fmt.Errorf("invalid %s operation limit: %w", wt, err)
+		}
+		if t.Daily, err = configMoney(turnover.Daily); err != nil {
+			return fmt.Errorf("invalid %s daily turnover limit: %w", wt, err)
+		}
+		if t.Monthly, err = configMoney(turnover.Monthly); err != nil {
+			return fmt.Errorf("invalid %s monthly turnover limit: %w", wt, err)
+		}
+		limits.turnover[wt] = t
+	}
+
+	p.limits.Store(limits)
+	return nil
+}
+
+// SupportsCurrency reports whether wallets may be opened in the currency.
+func (p *LimitsPolicy) SupportsCurrency(currency string) bool {
+	return p.limits.Load().currencies[currency]
+}
+
+// MaxBalance returns the highest balance the wallet may hold. Wallets of an
+// unknown type may not hold any money.
+func (p *LimitsPolicy) MaxBalance(wallet *models.Wallet) models.Money {
+	limits := p.limits.Load()
+	if byType, ok := limits.byCurrency[wallet.Currency]; ok {
+		if limit, ok := byType[wallet.Type]; ok {
+			return limit
+		}
+	}
+	return limits.byType[wallet.Type]
+}
+
+// TurnoverLimits returns the turnover limits of the wallet's type. Types
+// without configured limits get the zero value, which enforces nothing.
+func (p *LimitsPolicy) TurnoverLimits(wallet *models.Wallet) models.TurnoverLimits {
+	return p.limits.Load().turnover[wallet.Type]
+}
+
+func configWalletType(s string) (models.WalletType, error) {
+	wt := models.WalletType(strings.ToUpper(s))
+	if wt != models.WalletTypeIdentified && wt != models.WalletTypeUnidentified {
+		return "", fmt.Errorf("unknown wallet type %q in wallet limits", s)
+	}
+	return wt, nil
+}
+
+// configBalanceLimit converts a maximum balance, which must be positive: a
+// zero limit would reject every credit to the wallet.
+func configBalanceLimit(v float64) (models.Money, error) {
+	limit, err := configMoney(v)
+	if err != nil {
+		return 0, err
+	}
+	if !limit.IsPositive() {
+		return 0, fmt.Errorf("must be positive, got %s", limit)
+	}
+	return limit, nil
+}
+
+// configMoney converts a limit read from YAML. The shortest decimal
+// representation of the float is parsed, so 10000.5 stays exact.
+func configMoney(v float64) (models.Money, error) {
+	return models.ParseMoney(strconv.FormatFloat(v, 'f', -1, 64))
+}
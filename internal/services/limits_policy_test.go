@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestLimitsPolicy(t *testing.T) {
+	policy, err := NewLimitsPolicy(config.WalletConfig{
+		Currencies:        []string{"TJS", "usd"},
+		UnidentifiedLimit: 10_000,
+		IdentifiedLimit:   100_000,
+		// Keys arrive lower-cased from viper.
+		CurrencyLimits: map[string]map[string]float64{
+			"usd": {"unidentified": 1_000.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy: %v", err)
+	}
+
+	tests := []struct {
+		wallet *models.Wallet
+		want   string
+	}{
+		{models.NewWallet(uuid.New(), models.WalletTypeUnidentified, "TJS"), "10000.00"},
+		{models.NewWallet(uuid.New(), models.WalletTypeIdentified, "TJS"), "100000.00"},
+		{models.NewWallet(uuid.New(), models.WalletTypeUnidentified, "USD"), "1000.50"},
+		{models.NewWallet(uuid.New(), models.WalletTypeIdentified, "USD"), "100000.00"},
+	}
+	for _, tt := range tests {
+		if got := policy.MaxBalance(tt.wallet).String(); got != tt.want {
+			t.Errorf("%s %s: got %s; want %s", tt.wallet.Currency, tt.wallet.Type, got, tt.want)
+		}
+	}
+
+	if !policy.SupportsCurrency("USD") || policy.SupportsCurrency("EUR") {
+		t.Error("expected TJS and USD to be the only supported currencies")
+	}
+
+	if err := policy.Reload(config.WalletConfig{UnidentifiedLimit: 0.001}); err == nil {
+		t.Error("expected an error for a limit with three decimal places")
+	}
+	if got := policy.MaxBalance(tests[0].wallet).String(); got != "10000.00" {
+		t.Errorf("a failed reload must keep the previous limits; got %s", got)
+	}
+
+	if err := policy.Reload(config.WalletConfig{IdentifiedLimit: 100_000}); err == nil {
+		t.Error("expected an error for a missing unidentified wallet limit")
+	}
+	if err := policy.Reload(config.WalletConfig{
+		UnidentifiedLimit: 10_000,
+		IdentifiedLimit:   100_000,
+		CurrencyLimits:    map[string]map[string]float64{"usd": {"identified": -1}},
+	}); err == nil {
+		t.Error("expected an error for a negative currency limit")
+	}
+
+	if err := policy.Reload(config.WalletConfig{UnidentifiedLimit: 5_000, IdentifiedLimit: 50_000}); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if got := policy.MaxBalance(tests[0].wallet).String(); got != "5000.00" {
+		t.Errorf("expected reloaded limit 5000.00; got %s", got)
+	}
+}
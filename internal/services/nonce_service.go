@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRequestTimestampSkewed = errors.New("request timestamp is outside the allowed clock skew")
+	ErrNonceReused            = errors.New("nonce was already used")
+)
+
+// NonceService protects signed requests against replay. A request is accepted
+// only if its timestamp is within clockSkew of the server clock and its nonce
+// hasn't been used by the same client while such a timestamp was acceptable.
+type NonceService struct {
+	repo      repository.NonceRepository
+	clockSkew time.Duration
+}
+
+func NewNonceService(repo repository.NonceRepository, clockSkew time.Duration) *NonceService {
+	return &NonceService{repo: repo, clockSkew: clockSkew}
+}
+
+func (s *NonceService) CheckTimestamp(timestamp time.Time) error {
+	skew := time.Since(timestamp)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > s.clockSkew {
+		return ErrRequestTimestampSkewed
+	}
+	return nil
+}
+
+// Use records the nonce. A request with a timestamp accepted now may be
+// replayed for up to two skew windows, so the nonce is remembered that long.
+func (s *NonceService) Use(ctx context.Context, clientID uuid.UUID, nonce string) error {
+	now := time.Now()
+	stored, err := s.repo.Use(ctx, clientID, nonce, now, now.Add(2*s.clockSkew))
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+func (s *NonceService) DeleteExpiredNonces(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired request nonces", deleted)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrReversalReasonRequired = errors.New("reason for the reversal is required")
+
+// ReversalService undoes transactions, fully or in parts, with compensating
+// transactions linked to the original. Reversals move money through the same
+// accounts as the original in the opposite direction and are subject to the
+// same wallet status, available balance and maximum balance checks.
+type ReversalService struct {
+	transactor repository.Transactor
+	wallets    *WalletService
+}
+
+func NewReversalService(transactor repository.Transactor, wallets *WalletService) *ReversalService {
+	return &ReversalService{transactor: transactor, wallets: wallets}
+}
+
+// Reverse reverses amount of a completed transaction, or whatever has not
+// been reversed yet when amount is zero. Reversing a transfer reverses both
+// legs. It returns the compensating transactions.
+func (s *ReversalService) Reverse(ctx context.Context, transactionID uuid.UUID, amount models.Money, reason string) ([]*models.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReversalReasonRequired
+	}
+
+	var reversals []*models.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		originals, err := s.lockOriginals(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var reversed models.Money
+		walletIDs := make([]uuid.UUID, 0, len(originals))
+		for _, original := range originals {
+			if reversed, err = original.Reverse(amount, now); err != nil {
+				return err
+			}
+			walletIDs = append(walletIDs, original.WalletID)
+		}
+
+		wallets, err := s.lockWallets(ctx, walletIDs)
+		if err != nil {
+			return err
+		}
+
+		reversals = make([]*models.Transaction, 0, len(originals))
+		for _, original := range originals {
+			reversal := models.NewReversal(original, reversed, reason)
+			wallet := wallets[original.WalletID]
+			if reversal.Type.IsDebit() {
+				err = s.wallets.debit(ctx, wallet, reversed)
+			} else {
+				err = wallet.Credit(reversed, s.wallets.limits.MaxBalance(wallet))
+			}
+			if err != nil {
+				return err
+			}
+			if err := s.wallets.repo.UpdateBalance(ctx, wallet); err != nil {
+				return err
+			}
+			if err := s.wallets.transactionRepo.Create(ctx, reversal); err != nil {
+				return err
+			}
+			if err := s.wallets.transactionRepo.UpdateReversedAmount(ctx, original); err != nil {
+				return err
+			}
+			if original.Status == models.TransactionStatusReversed {
+				if err := s.wallets.transactionRepo.TransitionStatus(ctx, original, models.TransactionStatusCompleted); err != nil {
+					return err
+				}
+			}
+			reversals = append(reversals, reversal)
+		}
+
+		return s.post(ctx, originals, reversals, wallets, reversed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+// lockOriginals locks the transaction, or both legs of a transfer with the
+// outgoing leg first, so concurrent reversals of either leg queue up.
+func (s *ReversalService) lockOriginals(ctx context.Context, transactionID uuid.UUID) ([]*models.Transaction, error) {
+	original, err := s.wallets.transactionRepo.GetByID(ctx, transactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uuid.UUID{original.ID}
+	if original.TransferID != nil {
+		legs, err := s.wallets.transactionRepo.GetByTransferID(ctx, *original.TransferID)
+		if err != nil {
+			return nil, err
+		}
+		ids = ids[:0]
+		for _, leg := range legs {
+			if leg.Type == models.TransactionTypeTransferOut {
+				ids = append([]uuid.UUID{leg.ID}, ids...)
+			} else {
+				ids = append(ids, leg.ID)
+			}
+		}
+	}
+
+	originals := make([]*models.Transaction, 0, len(ids))
+	for _, id := range ids {
+		locked, err := s.wallets.transactionRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		originals = append(originals, locked)
+	}
+	return originals, nil
+}
+
+// lockWallets locks the wallets in a fixed order so that reversals and
+// transfers between the same wallets cannot deadlock.
+func (s *ReversalService) lockWallets(ctx context.Context, walletIDs []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	sorted := slices.Clone(walletIDs)
+	slices.SortFunc(sorted, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+
+	wallets := make(map[uuid.UUID]*models.Wallet, len(sorted))
+	for _, id := range sorted {
+		if _, ok := wallets[id]; ok {
+			continue
+		}
+		wallet, err := s.wallets.repo.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWalletNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		wallets[id] = wallet
+	}
+	return wallets, nil
+}
+
+// post records the reversal in the ledger. A transfer's reversal moves money
+// back between the two wallets; any other goes through the partner float.
+func (s *ReversalService) post(ctx context.Context, originals, reversals []*models.Transaction, wallets map[uuid.UUID]*models.Wallet, amount models.Money) error {
+	if len(originals) == 2 {
+		source, destination := originals[0].WalletID, originals[1].WalletID
+		entry := models.NewJournalEntry("Transfer reversal", &reversals[0].ID).Move(destination, source, amount)
+		return s.wallets.ledger.Post(ctx, entry)
+	}
+
+	reversal := reversals[0]
+	delta := amount
+	if reversal.Type.IsDebit() {
+		delta = -amount
+	}
+	return s.wallets.ledger.PostWithSystemAccount(ctx, models.SystemAccountPartnerFloat, wallets[reversal.WalletID], delta, reversal.Description, reversal.ID)
+}
@@ -2,22 +2,29 @@ package services
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type TransactionService struct {
 	repo repository.TransactionRepository
+	// wallets decides which wallets a client may read the history of.
+	wallets *WalletService
+	// location is the default timezone for calendar periods in statistics.
+	location *time.Location
 }
 
-func NewTransactionService(repo repository.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo repository.TransactionRepository, wallets *WalletService, location *time.Location) *TransactionService {
+	return &TransactionService{repo: repo, wallets: wallets, location: location}
 }
 
-func (s *TransactionService) CreateTransaction(ctx context.Context, walletID uuid.UUID, transactionType models.TransactionType, amount float64, description string) (*models.Transaction, error) {
+func (s *TransactionService) CreateTransaction(ctx context.Context, walletID uuid.UUID, transactionType models.TransactionType, amount models.Money, description string) (*models.Transaction, error) {
 	transaction := models.NewTransaction(walletID, transactionType, amount, description)
 	err := s.repo.Create(ctx, transaction)
 	if err != nil {
@@ -30,10 +37,120 @@ func (s *TransactionService) GetTransactionByID(ctx context.Context, id uuid.UUI
 	return s.repo.GetByID(ctx, id)
 }
 
+// GetClientTransaction returns the transaction if it belongs to a wallet the
+// client may use, and ErrTransactionNotFound otherwise.
+func (s *TransactionService) GetClientTransaction(ctx context.Context, client *models.Client, id uuid.UUID) (*models.Transaction, error) {
+	transaction, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.wallets.GetWallet(ctx, client, transaction.WalletID); err != nil {
+		if errors.Is(err, ErrWalletNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// GetTransactionByExternalRef returns the transaction the client initiated
+// with its own reference.
+func (s *TransactionService) GetTransactionByExternalRef(ctx context.Context, client *models.Client, externalRef string) (*models.Transaction, error) {
+	transaction, err := s.repo.GetByExternalRef(ctx, client.ID, externalRef)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, err
+}
+
 func (s *TransactionService) GetTransactionsByWalletID(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.Transaction, error) {
 	return s.repo.GetByWalletID(ctx, walletID, limit, offset)
 }
 
-func (s *TransactionService) GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error) {
-	return s.repo.GetMonthlyTopUpStats(ctx, walletID)
+// ListTransactions returns one page of a wallet's history and the cursor of
+// the next page, which is empty on the last page. Wallets the client may not
+// use fail with ErrWalletNotFound.
+func (s *TransactionService) ListTransactions(ctx context.Context, client *models.Client, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	if _, err := s.wallets.GetWallet(ctx, client, filter.WalletID); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultTransactionPageSize
+	}
+	if filter.Limit > models.MaxTransactionPageSize {
+		filter.Limit = models.MaxTransactionPageSize
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = models.CursorAfter(page.Transactions[limit-1]).Encode()
+	}
+	if page.Transactions == nil {
+		page.Transactions = []*models.Transaction{}
+	}
+
+	return page, nil
+}
+
+// GetStats aggregates the wallet's transactions per type over the requested
+// interval. Calendar periods are computed in the query's location, falling
+// back to the configured one, and default to the current month.
+func (s *TransactionService) GetStats(ctx context.Context, client *models.Client, walletID uuid.UUID, query models.StatsQuery) (*models.StatsReport, error) {
+	if _, err := s.wallets.GetWallet(ctx, client, walletID); err != nil {
+		return nil, err
+	}
+
+	loc := query.Location
+	if loc == nil {
+		loc = s.location
+	}
+
+	var from, to time.Time
+	if query.From != nil || query.To != nil {
+		if query.From == nil || query.To == nil || !query.From.Before(*query.To) {
+			return nil, models.ErrInvalidStatsRange
+		}
+		from, to = *query.From, *query.To
+	} else {
+		period := query.Period
+		if period == "" {
+			period = models.StatsPeriodMonth
+		}
+
+		var err error
+		from, to, err = period.Bounds(time.Now(), loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats, err := s.repo.GetStats(ctx, walletID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.StatsReport{
+		From:     from.In(loc),
+		To:       to.In(loc),
+		Timezone: loc.String(),
+		Stats:    make(map[models.TransactionType]*models.TransactionStats, len(stats)),
+	}
+	for _, st := range stats {
+		report.Stats[st.Type] = st
+	}
+
+	return report, nil
 }
@@ -1,57 +1,585 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrWalletNotFound       = errors.New("wallet not found")
+	ErrSameWallet           = errors.New("source and destination wallets must differ")
+	ErrCurrencyMismatch     = errors.New("wallets have different currencies")
+	ErrWalletOwnedByClient  = errors.New("client already owns the wallet")
+	ErrWalletAccessNotFound = errors.New("client has not been granted access to the wallet")
+	ErrWalletAlreadyOwned   = errors.New("wallet already has an owner")
+	ErrInvalidWalletType    = errors.New("wallet type must be IDENTIFIED or UNIDENTIFIED")
+	ErrUnsupportedCurrency  = errors.New("currency is not supported")
+	ErrDuplicateExternalRef = errors.New("a wallet with this external reference already exists")
+	ErrAmbiguousWallet      = errors.New("customer has several wallets; specify the currency")
+	ErrInvalidWalletStatus  = errors.New("wallet status must be ACTIVE, FROZEN_CREDITS, FROZEN_DEBITS, BLOCKED or CLOSED")
+	ErrStatusReasonRequired = errors.New("reason for the status change is required")
+	ErrWalletNotEmpty       = errors.New("only wallets with a zero balance can be closed")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	// ErrTransactionStatusChanged means another writer settled the
+	// transaction first.
+	ErrTransactionStatusChanged = errors.New("transaction status was changed concurrently")
+	ErrFailureReasonRequired    = errors.New("failure reason is required")
+	ErrDuplicateTransactionRef  = errors.New("a transaction with this external reference already exists")
 )
 
 type WalletService struct {
-	repo repository.WalletRepository
+	transactor      repository.Transactor
+	repo            repository.WalletRepository
+	transactionRepo repository.TransactionRepository
+	ledger          *LedgerService
+	limits          *LimitsEngine
+	customers       *CustomerService
+	statusRepo      repository.WalletStatusChangeRepository
+	holdRepo        repository.HoldRepository
 }
 
-func NewWalletService(repo repository.WalletRepository) *WalletService {
-	return &WalletService{repo: repo}
+func NewWalletService(transactor repository.Transactor, repo repository.WalletRepository, transactionRepo repository.TransactionRepository,
+	ledger *LedgerService, limits *LimitsEngine, customers *CustomerService,
+	statusRepo repository.WalletStatusChangeRepository, holdRepo repository.HoldRepository) *WalletService {
+	return &WalletService{
+		transactor:      transactor,
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+		limits:          limits,
+		customers:       customers,
+		statusRepo:      statusRepo,
+		holdRepo:        holdRepo,
+	}
 }
 
-func (s *WalletService) CheckWalletExists(ctx context.Context, walletID uuid.UUID) (bool, error) {
-	return s.repo.Exists(ctx, walletID)
+// CreateWallet opens an empty wallet owned by the client. The optional
+// externalRef is the client's own account reference and must be unique among
+// the client's wallets. A non-nil holder links the wallet to the customer with
+// the holder's phone number, who is created if new.
+func (s *WalletService) CreateWallet(ctx context.Context, client *models.Client, walletType models.WalletType, currency string, externalRef *string, holder *models.CustomerDetails) (*models.Wallet, error) {
+	if !walletType.Valid() {
+		return nil, ErrInvalidWalletType
+	}
+	currency = strings.ToUpper(currency)
+	if !s.limits.policy.SupportsCurrency(currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+	if holder != nil {
+		if _, err := s.customers.NormalizePhone(holder.Phone); err != nil {
+			return nil, err
+		}
+	}
+
+	wallet := models.NewWallet(client.ID, walletType, currency)
+	wallet.ExternalRef = externalRef
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// The currency may have been configured after the system accounts
+		// were created.
+		if err := s.ledger.EnsureSystemAccounts(ctx, currency); err != nil {
+			return err
+		}
+		if holder != nil {
+			customer, err := s.customers.GetOrCreateCustomer(ctx, *holder)
+			if err != nil {
+				return err
+			}
+			wallet.CustomerID = &customer.ID
+		}
+
+		err := s.repo.Create(ctx, *wallet)
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrDuplicateExternalRef
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
 }
 
-func (s *WalletService) TopUpWallet(ctx context.Context, walletID uuid.UUID, amount float64) error {
-	wallet, err := s.repo.GetByID(ctx, walletID)
+// ResolveWalletByPhone finds the wallet of the customer with the phone number
+// among the wallets the client may use. A non-empty currency narrows the
+// search; ErrAmbiguousWallet means more than one wallet matches.
+func (s *WalletService) ResolveWalletByPhone(ctx context.Context, client *models.Client, phone, currency string) (*models.Wallet, error) {
+	customer, err := s.customers.GetCustomerByPhone(ctx, phone)
+	if errors.Is(err, ErrCustomerNotFound) {
+		return nil, ErrWalletNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	wallets, err := s.repo.GetAccessibleByCustomer(ctx, customer.ID, client.ID, strings.ToUpper(currency))
+	if err != nil {
+		return nil, err
+	}
+	switch len(wallets) {
+	case 0:
+		return nil, ErrWalletNotFound
+	case 1:
+		return wallets[0], nil
+	default:
+		return nil, ErrAmbiguousWallet
+	}
+}
+
+// TopUpWallet credits the wallet and records the top-up under the client's
+// external reference, if given. It returns the transaction and the balance
+// after the top-up, and fails with ErrDuplicateTransactionRef when the client
+// has already used the reference.
+func (s *WalletService) TopUpWallet(ctx context.Context, client *models.Client, walletID uuid.UUID, amount models.Money, externalRef *string) (*models.Transaction, models.Money, error) {
+	transaction := models.NewTransaction(walletID, models.TransactionTypeTopUp, amount, "Top-up")
+	transaction.ExternalRef = externalRef
+	wallet, err := s.applyTransaction(ctx, client, transaction)
+	if err != nil {
+		return nil, 0, err
 	}
+	return transaction, wallet.Balance, nil
+}
+
+// WithdrawWallet debits the wallet and records the withdrawal. It fails with
+// models.ErrInsufficientFunds when the available balance does not cover the
+// amount.
+func (s *WalletService) WithdrawWallet(ctx context.Context, client *models.Client, walletID uuid.UUID, amount models.Money) error {
+	_, err := s.applyTransaction(ctx, client, models.NewTransaction(walletID, models.TransactionTypeWithdraw, amount, "Withdrawal"))
+	return err
+}
+
+// applyTransaction changes the balance and records the transaction together
+// with its journal entry against the partner float account. The wallet row
+// stays locked from the balance check until the commit, so concurrent
+// operations on the same wallet are applied one after another. It returns the
+// wallet as updated.
+func (s *WalletService) applyTransaction(ctx context.Context, client *models.Client, transaction *models.Transaction) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if wallet, err = s.lockWallet(ctx, client, transaction.WalletID); err != nil {
+			return err
+		}
 
-	if wallet == nil {
-		return errors.New("wallet not found")
+		transaction.ClientID = &client.ID
+		if err := s.applyBalanceEffect(ctx, wallet, transaction); err != nil {
+			return err
+		}
+		if err := s.createTransaction(ctx, transaction); err != nil {
+			return err
+		}
+		return s.postToPartnerFloat(ctx, wallet, transaction)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return wallet, nil
+}
 
-	err = wallet.UpdateBalance(amount)
+// TopUpWalletPending records a top-up that an external rail, such as a bank
+// transfer, settles later. The balance changes only when the transaction is
+// completed; until then it stays PENDING and can still be failed. A top-up
+// the wallet could never accept, because of its status or the size of the
+// operation, is rejected up front; the turnover and balance limits depend on
+// what else settles first and are checked on completion.
+func (s *WalletService) TopUpWalletPending(ctx context.Context, client *models.Client, walletID uuid.UUID, amount models.Money, externalRef *string) (*models.Transaction, error) {
+	wallet, err := s.GetWallet(ctx, client, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := wallet.CanCredit(); err != nil {
+		return nil, err
+	}
+	if err := s.limits.CheckOperation(wallet, amount); err != nil {
+		return nil, err
+	}
+
+	transaction := models.NewPendingTransaction(wallet.ID, models.TransactionTypeTopUp, amount, "Top-up")
+	transaction.ClientID = &client.ID
+	transaction.ExternalRef = externalRef
+	if err := s.createTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// CompleteTransaction settles a pending transaction and applies its balance
+// effect, subject to the same checks as a synchronous operation. Of two
+// callers completing or failing the same transaction only the first succeeds;
+// the other gets ErrTransactionStatusChanged.
+func (s *WalletService) CompleteTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if transaction, err = s.getTransaction(ctx, transactionID); err != nil {
+			return err
+		}
+		wallet, err := s.repo.GetByIDForUpdate(ctx, transaction.WalletID)
+		if err != nil {
+			return err
+		}
+
+		if err := transaction.Complete(time.Now()); err != nil {
+			return err
+		}
+		if err := s.applyBalanceEffect(ctx, wallet, transaction); err != nil {
+			return err
+		}
+		if err := s.transitionStatus(ctx, transaction, models.TransactionStatusPending); err != nil {
+			return err
+		}
+		return s.postToPartnerFloat(ctx, wallet, transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// FailTransaction settles a pending transaction without changing the balance.
+func (s *WalletService) FailTransaction(ctx context.Context, transactionID uuid.UUID, reason string) (*models.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrFailureReasonRequired
+	}
+
+	transaction, err := s.getTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transaction.Fail(reason, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.transitionStatus(ctx, transaction, models.TransactionStatusPending); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *WalletService) createTransaction(ctx context.Context, transaction *models.Transaction) error {
+	err := s.transactionRepo.Create(ctx, transaction)
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrDuplicateTransactionRef
+	}
+	return err
+}
+
+func (s *WalletService) getTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, err
+}
+
+func (s *WalletService) transitionStatus(ctx context.Context, transaction *models.Transaction, from models.TransactionStatus) error {
+	err := s.transactionRepo.TransitionStatus(ctx, transaction, from)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return ErrTransactionStatusChanged
+	}
+	return err
+}
+
+// applyBalanceEffect debits or credits the locked wallet by the transaction's
+// amount and saves the new balance.
+func (s *WalletService) applyBalanceEffect(ctx context.Context, wallet *models.Wallet, transaction *models.Transaction) error {
+	var err error
+	if transaction.Type.IsDebit() {
+		err = s.debit(ctx, wallet, transaction.Amount)
+	} else {
+		err = s.credit(ctx, wallet, transaction.Amount)
+	}
 	if err != nil {
 		return err
 	}
+	return s.repo.UpdateBalance(ctx, wallet)
+}
 
-	return s.repo.Update(ctx, wallet, amount)
+// postToPartnerFloat records the transaction's journal entry against the
+// partner float account.
+func (s *WalletService) postToPartnerFloat(ctx context.Context, wallet *models.Wallet, transaction *models.Transaction) error {
+	delta := transaction.Amount
+	if transaction.Type.IsDebit() {
+		delta = -delta
+	}
+	return s.ledger.PostWithSystemAccount(ctx, models.SystemAccountPartnerFloat, wallet, delta, transaction.Description, transaction.ID)
 }
 
-func (s *WalletService) GetMonthlyTopUpStats(ctx context.Context, walletID uuid.UUID) (int, float64, error) {
-	return s.repo.GetMonthlyTopUpStats(ctx, walletID)
+// Transfer moves money from one wallet to another in a single database
+// transaction and records a TRANSFER_OUT and a TRANSFER_IN transaction sharing
+// the returned transfer ID. The destination's maximum balance is enforced.
+// The client must have access to both wallets.
+func (s *WalletService) Transfer(ctx context.Context, client *models.Client, sourceID, destinationID uuid.UUID, amount models.Money, description string) (uuid.UUID, error) {
+	if sourceID == destinationID {
+		return uuid.Nil, ErrSameWallet
+	}
+
+	transferID := uuid.New()
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock both rows in a fixed order so opposite transfers between the
+		// same two wallets cannot deadlock.
+		firstID, secondID := sourceID, destinationID
+		if bytes.Compare(firstID[:], secondID[:]) > 0 {
+			firstID, secondID = secondID, firstID
+		}
+		first, err := s.lockWallet(ctx, client, firstID)
+		if err != nil {
+			return err
+		}
+		second, err := s.lockWallet(ctx, client, secondID)
+		if err != nil {
+			return err
+		}
+
+		source, destination := first, second
+		if source.ID != sourceID {
+			source, destination = second, first
+		}
+
+		if source.Currency != destination.Currency {
+			return ErrCurrencyMismatch
+		}
+		if err := s.debit(ctx, source, amount); err != nil {
+			return err
+		}
+		if err := s.credit(ctx, destination, amount); err != nil {
+			return err
+		}
+
+		for _, wallet := range []*models.Wallet{source, destination} {
+			if err := s.repo.UpdateBalance(ctx, wallet); err != nil {
+				return err
+			}
+		}
+
+		out := models.NewTransaction(source.ID, models.TransactionTypeTransferOut, amount, description)
+		out.TransferID = &transferID
+		if err := s.transactionRepo.Create(ctx, out); err != nil {
+			return err
+		}
+
+		in := models.NewTransaction(destination.ID, models.TransactionTypeTransferIn, amount, description)
+		in.TransferID = &transferID
+		if err := s.transactionRepo.Create(ctx, in); err != nil {
+			return err
+		}
+
+		entry := models.NewJournalEntry("Transfer", &transferID).Move(source.ID, destination.ID, amount)
+		return s.ledger.Post(ctx, entry)
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return transferID, nil
 }
 
-func (s *WalletService) GetBalance(ctx context.Context, walletID uuid.UUID) (float64, error) {
-	wallet, err := s.repo.GetByID(ctx, walletID)
+// credit checks the wallet's status and limits and adds amount to its balance.
+func (s *WalletService) credit(ctx context.Context, wallet *models.Wallet, amount models.Money) error {
+	if err := wallet.CanCredit(); err != nil {
+		return err
+	}
+	if err := s.limits.CheckCredit(ctx, wallet, amount); err != nil {
+		return err
+	}
+	return wallet.Credit(amount, s.limits.MaxBalance(wallet))
+}
+
+// debit takes amount from the balance unless active holds reserve the money.
+func (s *WalletService) debit(ctx context.Context, wallet *models.Wallet, amount models.Money) error {
+	if err := wallet.CanDebit(); err != nil {
+		return err
+	}
+	available, err := s.AvailableBalance(ctx, wallet)
+	if err != nil {
+		return err
+	}
+	if amount > available {
+		return models.ErrInsufficientFunds
+	}
+	return wallet.Debit(amount)
+}
+
+// AvailableBalance is the part of the wallet's balance that active holds do
+// not reserve.
+func (s *WalletService) AvailableBalance(ctx context.Context, wallet *models.Wallet) (models.Money, error) {
+	held, err := s.holdRepo.GetHeldAmount(ctx, wallet.ID, time.Now())
 	if err != nil {
 		return 0, err
 	}
+	return wallet.Balance - held, nil
+}
+
+func (s *WalletService) lockWallet(ctx context.Context, client *models.Client, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.repo.GetByIDForUpdate(ctx, walletID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, client, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// authorize fails with ErrWalletNotFound unless the client owns the wallet or
+// has been granted access, so foreign wallets look like missing ones.
+func (s *WalletService) authorize(ctx context.Context, client *models.Client, wallet *models.Wallet) error {
+	if wallet.ClientID == client.ID {
+		return nil
+	}
+	ok, err := s.repo.HasAccess(ctx, wallet.ID, client.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWalletNotFound
+	}
+	return nil
+}
 
-	if wallet == nil {
-		return 0, errors.New("wallet not found")
+func (s *WalletService) GetBalance(ctx context.Context, client *models.Client, walletID uuid.UUID) (models.Money, error) {
+	wallet, err := s.GetWallet(ctx, client, walletID)
+	if err != nil {
+		return 0, err
 	}
 
 	return wallet.Balance, nil
 }
+
+// GetWallet returns the wallet if the client may use it, and
+// ErrWalletNotFound otherwise.
+func (s *WalletService) GetWallet(ctx context.Context, client *models.Client, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, client, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetWalletByID returns the wallet regardless of which client owns it. It is
+// meant for administrative use only.
+func (s *WalletService) GetWalletByID(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.repo.GetByID(ctx, walletID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, err
+}
+
+// AssignOwner makes the client the owner of a wallet that predates ownership.
+// Wallets that already have an owner fail with ErrWalletAlreadyOwned.
+func (s *WalletService) AssignOwner(ctx context.Context, walletID, clientID uuid.UUID) error {
+	if _, err := s.GetWalletByID(ctx, walletID); err != nil {
+		return err
+	}
+	assigned, err := s.repo.AssignOwner(ctx, walletID, clientID)
+	if err != nil {
+		return err
+	}
+	if !assigned {
+		return ErrWalletAlreadyOwned
+	}
+	return nil
+}
+
+// GetUnownedWalletIDs returns the wallets that still wait for an owner.
+func (s *WalletService) GetUnownedWalletIDs(ctx context.Context) ([]uuid.UUID, error) {
+	return s.repo.GetUnownedIDs(ctx)
+}
+
+// GrantAccess lets a client other than the owner use the wallet.
+func (s *WalletService) GrantAccess(ctx context.Context, walletID, clientID uuid.UUID) error {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if wallet.ClientID == clientID {
+		return ErrWalletOwnedByClient
+	}
+	return s.repo.GrantAccess(ctx, walletID, clientID)
+}
+
+func (s *WalletService) RevokeAccess(ctx context.Context, walletID, clientID uuid.UUID) error {
+	if _, err := s.GetWalletByID(ctx, walletID); err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokeAccess(ctx, walletID, clientID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrWalletAccessNotFound
+	}
+	return nil
+}
+
+// GetGrantedClientIDs returns the clients besides the owner that may use the wallet.
+func (s *WalletService) GetGrantedClientIDs(ctx context.Context, walletID uuid.UUID) ([]uuid.UUID, error) {
+	if _, err := s.GetWalletByID(ctx, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGrantedClientIDs(ctx, walletID)
+}
+
+// ChangeStatus moves the wallet to another status and records who did it and
+// why. Only wallets with a zero balance can be closed.
+func (s *WalletService) ChangeStatus(ctx context.Context, walletID uuid.UUID, to models.WalletStatus, reason, actor string) (*models.WalletStatusChange, error) {
+	if !to.Valid() {
+		return nil, ErrInvalidWalletStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrStatusReasonRequired
+	}
+
+	var change *models.WalletStatusChange
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		wallet, err := s.repo.GetByIDForUpdate(ctx, walletID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !wallet.Status.CanTransitionTo(to) {
+			return fmt.Errorf("%w: %s to %s", models.ErrInvalidStatusTransition, wallet.Status, to)
+		}
+		if to == models.WalletStatusClosed && wallet.Balance != 0 {
+			return ErrWalletNotEmpty
+		}
+
+		change = models.NewWalletStatusChange(wallet.ID, wallet.Status, to, reason, actor)
+		wallet.Status = to
+		if err := s.repo.UpdateStatus(ctx, wallet); err != nil {
+			return err
+		}
+		return s.statusRepo.Create(ctx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// GetStatusHistory returns the wallet's status changes, newest first.
+func (s *WalletService) GetStatusHistory(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error) {
+	if _, err := s.GetWalletByID(ctx, walletID); err != nil {
+		return nil, err
+	}
+	return s.statusRepo.GetByWalletID(ctx, walletID)
+}
@@ -3,9 +3,15 @@ package hmac
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
+	"strings"
 )
 
+// SignatureVersion2 prefixes the canonical string of HMAC-SHA256 signatures so
+// that a future scheme can't be confused with this one.
+const SignatureVersion2 = "v2"
+
 func CalculateHMAC(message, secretKey string) string {
 	//message = normalizeMessage(message)
 	key := []byte(secretKey)
@@ -20,6 +26,32 @@ func ValidateHMAC(message, secretKey, digest string) bool {
 	return hmac.Equal([]byte(digest), []byte(expectedMAC))
 }
 
+// CanonicalString builds the message signed by HMAC-SHA256 clients: the
+// version, method, path, timestamp, nonce and the hex SHA-256 of the body,
+// separated by newlines.
+func CanonicalString(method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		SignatureVersion2,
+		strings.ToUpper(method),
+		path,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+func CalculateHMACSHA256(message, secretKey string) string {
+	h := hmac.New(sha256.New, []byte(secretKey))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func ValidateHMACSHA256(message, secretKey, digest string) bool {
+	expectedMAC := CalculateHMACSHA256(message, secretKey)
+	return hmac.Equal([]byte(strings.ToLower(digest)), []byte(expectedMAC))
+}
+
 //func normalizeMessage(message string) string {
 //	whitespaceRegex := regexp.MustCompile(`\s+`)
 //	newlineRegex := regexp.MustCompile(`[\n\r]`)
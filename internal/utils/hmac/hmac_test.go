@@ -0,0 +1,23 @@
+package hmac
+
+import "testing"
+
+func TestCanonicalString(t *testing.T) {
+	got := CanonicalString("post", "/api/v1/wallet/balance", "1700000000", "n-1", []byte(""))
+	want := "v2\nPOST\n/api/v1/wallet/balance\n1700000000\nn-1\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestValidateHMACSHA256(t *testing.T) {
+	// RFC 4231 test case 2.
+	const digest = "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if !ValidateHMACSHA256("what do ya want for nothing?", "Jefe", digest) {
+		t.Error("expected the RFC 4231 digest to validate")
+	}
+	if ValidateHMACSHA256("what do ya want for nothing!", "Jefe", digest) {
+		t.Error("expected a different message to be rejected")
+	}
+}
@@ -0,0 +1,92 @@
+// Package secrets encrypts values at rest with AES-256-GCM master keys.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of a master key in bytes (AES-256).
+const KeySize = 32
+
+var (
+	ErrUnknownKey        = errors.New("unknown master key")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// Keyring holds the master keys by ID. New values are encrypted with the
+// current key; older keys are kept so existing values can still be decrypted
+// until they are re-encrypted.
+type Keyring struct {
+	currentKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from base64-encoded master keys.
+func NewKeyring(currentKeyID string, keys map[string]string) (*Keyring, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("current master key %q is not configured", currentKeyID)
+	}
+
+	k := &Keyring{currentKeyID: currentKeyID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func (k *Keyring) CurrentKeyID() string {
+	return k.currentKeyID
+}
+
+// Encrypt seals plaintext with the current key. additionalData binds the
+// ciphertext to its owner, e.g. a row ID, so it can't be moved to another row.
+// It returns the base64 ciphertext and the ID of the key used.
+func (k *Keyring) Encrypt(plaintext string, additionalData []byte) (string, string, error) {
+	aead := k.keys[k.currentKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), k.currentKeyID, nil
+}
+
+func (k *Keyring) Decrypt(ciphertext, keyID string, additionalData []byte) (string, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
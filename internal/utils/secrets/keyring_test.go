@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, KeySize))
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := NewKeyring("k1", map[string]string{"k1": testKey(1)})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	ciphertext, keyID, err := old.Encrypt("s3cret", []byte("client-1"))
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if keyID != "k1" {
+		t.Errorf("got key ID %q; want k1", keyID)
+	}
+
+	rotated, err := NewKeyring("k2", map[string]string{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+	plaintext, err := rotated.Decrypt(ciphertext, keyID, []byte("client-1"))
+	if err != nil || plaintext != "s3cret" {
+		t.Fatalf("got %q, %v; want s3cret", plaintext, err)
+	}
+
+	if _, err := rotated.Decrypt(ciphertext, keyID, []byte("client-2")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("expected ErrInvalidCiphertext for other additional data, got %v", err)
+	}
+	if _, err := rotated.Decrypt(ciphertext, "k3", []byte("client-1")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+	if _, _, err := rotated.Encrypt("s3cret", nil); err != nil {
+		t.Errorf("encrypt with rotated key failed: %v", err)
+	}
+}
+
+func TestNewKeyringRejectsBadKeys(t *testing.T) {
+	if _, err := NewKeyring("k1", map[string]string{"k2": testKey(2)}); err == nil {
+		t.Error("expected an error when the current key is missing")
+	}
+	if _, err := NewKeyring("k1", map[string]string{"k1": "c2hvcnQ="}); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
@@ -0,0 +1,6 @@
+-- Postgres cannot drop a single enum value, so the type is rebuilt without it.
+-- This fails while WITHDRAW transactions still exist.
+ALTER TYPE transaction_type RENAME TO transaction_type_old;
+CREATE TYPE transaction_type AS ENUM ('TOP_UP');
+ALTER TABLE transactions ALTER COLUMN type TYPE transaction_type USING type::text::transaction_type;
+DROP TYPE transaction_type_old;
@@ -0,0 +1 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'WITHDRAW';
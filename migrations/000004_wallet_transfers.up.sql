@@ -0,0 +1,6 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'TRANSFER_OUT';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'TRANSFER_IN';
+
+ALTER TABLE transactions ADD COLUMN transfer_id UUID;
+
+CREATE INDEX idx_transactions_transfer_id ON transactions(transfer_id) WHERE transfer_id IS NOT NULL;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS wallets_ledger_account ON wallets;
+DROP FUNCTION IF EXISTS create_wallet_ledger_account();
+
+DROP TABLE IF EXISTS postings;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP TYPE IF EXISTS ledger_account_type;
@@ -0,0 +1,95 @@
+CREATE TYPE ledger_account_type AS ENUM ('WALLET', 'SYSTEM');
+
+-- Every wallet is a ledger account sharing the wallet's ID. System accounts
+-- (partner float, fees, suspense) exist once per currency.
+CREATE TABLE ledger_accounts (
+    id UUID PRIMARY KEY,
+    type ledger_account_type NOT NULL,
+    code VARCHAR(64),
+    wallet_id UUID UNIQUE,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (wallet_id) REFERENCES wallets(id),
+    UNIQUE (code, currency),
+    CHECK ((type = 'WALLET' AND wallet_id = id AND code IS NULL) OR (type = 'SYSTEM' AND wallet_id IS NULL AND code IS NOT NULL))
+);
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY,
+    description TEXT NOT NULL,
+    reference_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_journal_entries_reference_id ON journal_entries(reference_id);
+
+-- Posting amounts are signed: a positive amount increases the account balance.
+CREATE TABLE postings (
+    id UUID PRIMARY KEY,
+    journal_entry_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id),
+    FOREIGN KEY (account_id) REFERENCES ledger_accounts(id)
+);
+
+CREATE INDEX idx_postings_journal_entry_id ON postings(journal_entry_id);
+CREATE INDEX idx_postings_account_id ON postings(account_id);
+
+-- The postings of a journal entry must sum to zero. The check is deferred to
+-- commit so all postings of an entry can be inserted first.
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM postings WHERE journal_entry_id = NEW.journal_entry_id) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+CREATE FUNCTION create_wallet_ledger_account() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO ledger_accounts (id, type, wallet_id, currency, created_at)
+    VALUES (NEW.id, 'WALLET', NEW.id, NEW.currency, NEW.created_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_ledger_account
+    AFTER INSERT ON wallets
+    FOR EACH ROW EXECUTE FUNCTION create_wallet_ledger_account();
+
+-- Backfill: system accounts for every currency in use, a ledger account for
+-- every existing wallet, and an opening entry moving its current balance out
+-- of suspense.
+INSERT INTO ledger_accounts (id, type, code, currency)
+SELECT gen_random_uuid(), 'SYSTEM', code, currency
+FROM (VALUES ('PARTNER_FLOAT'), ('FEES'), ('SUSPENSE')) AS codes(code)
+CROSS JOIN (SELECT currency FROM wallets UNION SELECT 'TJS') AS currencies;
+
+INSERT INTO ledger_accounts (id, type, wallet_id, currency, created_at)
+SELECT id, 'WALLET', id, currency, created_at FROM wallets;
+
+CREATE TEMPORARY TABLE opening_entries AS
+SELECT gen_random_uuid() AS entry_id, id AS wallet_id, balance, currency
+FROM wallets
+WHERE balance <> 0;
+
+INSERT INTO journal_entries (id, description, reference_id)
+SELECT entry_id, 'Opening balance', wallet_id FROM opening_entries;
+
+INSERT INTO postings (id, journal_entry_id, account_id, amount)
+SELECT gen_random_uuid(), entry_id, wallet_id, balance FROM opening_entries;
+
+INSERT INTO postings (id, journal_entry_id, account_id, amount)
+SELECT gen_random_uuid(), o.entry_id, a.id, -o.balance
+FROM opening_entries o
+JOIN ledger_accounts a ON a.type = 'SYSTEM' AND a.code = 'SUSPENSE' AND a.currency = o.currency;
+
+DROP TABLE opening_entries;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_transactions_wallet_id ON transactions(wallet_id);
+
+DROP INDEX IF EXISTS idx_transactions_wallet_created_at_id;
@@ -0,0 +1,4 @@
+-- Serves keyset pagination of a wallet's history ordered by (created_at, id).
+CREATE INDEX idx_transactions_wallet_created_at_id ON transactions(wallet_id, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_transactions_wallet_id;
@@ -0,0 +1,15 @@
+CREATE TABLE client_suspensions (
+    id UUID PRIMARY KEY,
+    client_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    suspended_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    suspended_by VARCHAR(255) NOT NULL,
+    reactivate_at TIMESTAMP WITH TIME ZONE,
+    lifted_at TIMESTAMP WITH TIME ZONE,
+    lifted_by VARCHAR(255),
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+
+-- A client has at most one suspension in effect.
+CREATE UNIQUE INDEX idx_client_suspensions_current ON client_suspensions(client_id) WHERE lifted_at IS NULL;
+CREATE INDEX idx_client_suspensions_reactivate_at ON client_suspensions(reactivate_at) WHERE lifted_at IS NULL;
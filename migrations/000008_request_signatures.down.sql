@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS request_nonces;
+
+ALTER TABLE clients DROP COLUMN IF EXISTS signature_scheme;
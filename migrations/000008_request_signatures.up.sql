@@ -0,0 +1,13 @@
+ALTER TABLE clients
+    ADD COLUMN signature_scheme VARCHAR(32) NOT NULL DEFAULT 'HMAC-SHA1'
+        CHECK (signature_scheme IN ('HMAC-SHA1', 'HMAC-SHA256'));
+
+CREATE TABLE request_nonces (
+    client_id UUID NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (client_id, nonce),
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_request_nonces_expires_at ON request_nonces(expires_at);
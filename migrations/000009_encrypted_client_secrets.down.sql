@@ -0,0 +1,5 @@
+-- Encrypted secrets can't be decrypted here; re-create the affected clients'
+-- credentials after rolling back.
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS secret_key_id,
+    ALTER COLUMN secret_key TYPE VARCHAR(255);
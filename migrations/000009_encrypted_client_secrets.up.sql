@@ -0,0 +1,6 @@
+-- Secrets are stored AES-GCM encrypted and base64 encoded, which no longer
+-- fits in 255 characters. Rows with a NULL secret_key_id still hold a
+-- plaintext secret until the secrets are re-encrypted.
+ALTER TABLE clients
+    ALTER COLUMN secret_key TYPE TEXT,
+    ADD COLUMN secret_key_id VARCHAR(64);
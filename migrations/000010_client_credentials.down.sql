@@ -0,0 +1,19 @@
+ALTER TABLE clients
+    ADD COLUMN secret_key TEXT,
+    ADD COLUMN secret_key_id VARCHAR(64);
+
+-- Keep the newest unrevoked credential of each client.
+UPDATE clients
+SET secret_key = c.secret, secret_key_id = c.secret_key_id
+FROM (
+    SELECT DISTINCT ON (client_id) client_id, secret, secret_key_id
+    FROM client_credentials
+    WHERE revoked_at IS NULL
+    ORDER BY client_id, not_before DESC
+) c
+WHERE c.client_id = clients.id;
+
+UPDATE clients SET secret_key = '' WHERE secret_key IS NULL;
+ALTER TABLE clients ALTER COLUMN secret_key SET NOT NULL;
+
+DROP TABLE IF EXISTS client_credentials;
@@ -0,0 +1,22 @@
+CREATE TABLE client_credentials (
+    id UUID PRIMARY KEY,
+    client_id UUID NOT NULL,
+    secret TEXT NOT NULL,
+    secret_key_id VARCHAR(64),
+    not_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_client_credentials_client_id ON client_credentials(client_id);
+
+-- Every client keeps its current secret as its first credential.
+INSERT INTO client_credentials (id, client_id, secret, secret_key_id, not_before)
+SELECT gen_random_uuid(), id, secret_key, secret_key_id, created_at
+FROM clients;
+
+ALTER TABLE clients
+    DROP COLUMN secret_key,
+    DROP COLUMN secret_key_id;
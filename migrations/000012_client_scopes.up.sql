@@ -0,0 +1,4 @@
+ALTER TABLE clients ADD COLUMN scopes TEXT[] NOT NULL DEFAULT '{}';
+
+-- Existing clients could call every endpoint, so they keep every scope.
+UPDATE clients SET scopes = ARRAY['wallet:read', 'wallet:topup', 'wallet:withdraw', 'wallet:transfer'];
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_access;
+ALTER TABLE wallets DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1,24 @@
+ALTER TABLE wallets ADD COLUMN client_id UUID REFERENCES clients(id);
+
+-- Clients that may use a wallet besides its owner.
+CREATE TABLE wallet_access (
+    wallet_id  UUID        NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    client_id  UUID        NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, client_id)
+);
+
+CREATE INDEX idx_wallet_access_client_id ON wallet_access(client_id);
+
+-- Nothing recorded so far tells which client a wallet belongs to. With a
+-- single client there is only one possible owner, so existing wallets go to
+-- it. With several clients they are left without an owner and no client can
+-- use them until one is assigned: run
+--   wallet-owners assign -client <id> -wallets <file>
+-- for each client in the same release (see cmd/wallet-owners). Wallets created
+-- from now on always have an owner.
+UPDATE wallets
+SET client_id = (SELECT id FROM clients)
+WHERE (SELECT COUNT(*) FROM clients) = 1;
+
+CREATE INDEX idx_wallets_client_id ON wallets(client_id);
@@ -0,0 +1,5 @@
+UPDATE clients SET scopes = array_remove(scopes, 'wallet:create');
+DROP INDEX IF EXISTS idx_wallets_client_external_ref;
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS external_ref,
+    DROP COLUMN IF EXISTS holder_phone;
@@ -0,0 +1,12 @@
+ALTER TABLE wallets
+    ADD COLUMN external_ref TEXT,
+    ADD COLUMN holder_phone TEXT;
+
+-- A partner's own account reference identifies at most one of its wallets.
+CREATE UNIQUE INDEX idx_wallets_client_external_ref ON wallets(client_id, external_ref)
+    WHERE external_ref IS NOT NULL;
+
+-- Opening a wallet is the first step of funding one, so clients that may top
+-- up wallets may also open them.
+UPDATE clients SET scopes = array_append(scopes, 'wallet:create')
+WHERE 'wallet:topup' = ANY(scopes);
@@ -0,0 +1,9 @@
+ALTER TABLE wallets ADD COLUMN holder_phone TEXT;
+
+UPDATE wallets w
+SET holder_phone = c.phone
+FROM customers c
+WHERE c.id = w.customer_id;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,28 @@
+CREATE TABLE customers (
+    id                    UUID PRIMARY KEY,
+    phone                 TEXT        NOT NULL UNIQUE CHECK (phone ~ '^\+[1-9][0-9]{6,14}$'),
+    name                  TEXT,
+    date_of_birth         DATE,
+    identification_status TEXT        NOT NULL DEFAULT 'UNIDENTIFIED'
+        CHECK (identification_status IN ('UNIDENTIFIED', 'IDENTIFIED')),
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE wallets ADD COLUMN customer_id UUID REFERENCES customers(id);
+
+CREATE INDEX idx_wallets_customer_id ON wallets(customer_id);
+
+-- Holder phone numbers were validated as E.164 when the wallets were created.
+INSERT INTO customers (id, phone)
+SELECT gen_random_uuid(), holder_phone
+FROM wallets
+WHERE holder_phone IS NOT NULL
+GROUP BY holder_phone;
+
+UPDATE wallets w
+SET customer_id = c.id
+FROM customers c
+WHERE c.phone = w.holder_phone;
+
+ALTER TABLE wallets DROP COLUMN holder_phone;
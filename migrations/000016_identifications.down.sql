@@ -0,0 +1,2 @@
+UPDATE clients SET scopes = array_remove(scopes, 'wallet:identify');
+DROP TABLE IF EXISTS identifications;
@@ -0,0 +1,27 @@
+CREATE TABLE identifications (
+    id                  UUID PRIMARY KEY,
+    wallet_id           UUID        NOT NULL REFERENCES wallets(id),
+    document_type       TEXT        NOT NULL CHECK (document_type IN ('PASSPORT', 'NATIONAL_ID', 'DRIVERS_LICENSE')),
+    document_number     TEXT        NOT NULL,
+    document_expires_at DATE        NOT NULL,
+    status              TEXT        NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'EXPIRED', 'SUPERSEDED')),
+    rejection_reason    TEXT,
+    submitted_by        TEXT        NOT NULL,
+    reviewed_by         TEXT,
+    reviewed_at         TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_identifications_wallet_id ON identifications(wallet_id, created_at DESC);
+CREATE INDEX idx_identifications_status ON identifications(status, created_at);
+
+-- A wallet has at most one identification awaiting review and one in effect.
+CREATE UNIQUE INDEX idx_identifications_pending ON identifications(wallet_id) WHERE status = 'PENDING';
+CREATE UNIQUE INDEX idx_identifications_approved ON identifications(wallet_id) WHERE status = 'APPROVED';
+
+-- Identifying the holder is part of onboarding, so clients that may open
+-- wallets may also submit their holders' documents.
+UPDATE clients SET scopes = array_append(scopes, 'wallet:identify')
+WHERE 'wallet:create' = ANY(scopes);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_status_changes;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status;
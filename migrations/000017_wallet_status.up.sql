@@ -0,0 +1,14 @@
+ALTER TABLE wallets ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('ACTIVE', 'FROZEN_CREDITS', 'FROZEN_DEBITS', 'BLOCKED', 'CLOSED'));
+
+CREATE TABLE wallet_status_changes (
+    id          UUID PRIMARY KEY,
+    wallet_id   UUID        NOT NULL REFERENCES wallets(id),
+    from_status TEXT        NOT NULL,
+    to_status   TEXT        NOT NULL,
+    reason      TEXT        NOT NULL,
+    actor       TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_status_changes_wallet_id ON wallet_status_changes(wallet_id, created_at DESC);
@@ -0,0 +1,9 @@
+UPDATE clients SET scopes = array_remove(scopes, 'wallet:hold');
+DROP TABLE IF EXISTS holds;
+
+-- Postgres cannot drop a single enum value, so the type is rebuilt without it.
+-- This fails while HOLD_CAPTURE transactions still exist.
+ALTER TYPE transaction_type RENAME TO transaction_type_old;
+CREATE TYPE transaction_type AS ENUM ('TOP_UP', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN');
+ALTER TABLE transactions ALTER COLUMN type TYPE transaction_type USING type::text::transaction_type;
+DROP TYPE transaction_type_old;
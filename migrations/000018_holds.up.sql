@@ -0,0 +1,25 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'HOLD_CAPTURE';
+
+CREATE TABLE holds (
+    id                     UUID PRIMARY KEY,
+    wallet_id              UUID           NOT NULL REFERENCES wallets(id),
+    client_id              UUID           NOT NULL REFERENCES clients(id),
+    amount                 NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    captured_amount        NUMERIC(15, 2) NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    description            TEXT           NOT NULL DEFAULT '',
+    status                 TEXT           NOT NULL DEFAULT 'ACTIVE'
+        CHECK (status IN ('ACTIVE', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    capture_transaction_id UUID REFERENCES transactions(id),
+    expires_at             TIMESTAMPTZ    NOT NULL,
+    created_at             TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at             TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+-- Only active holds reduce the available balance and need expiring.
+CREATE INDEX idx_holds_active_wallet_id ON holds(wallet_id) WHERE status = 'ACTIVE';
+CREATE INDEX idx_holds_active_expires_at ON holds(expires_at) WHERE status = 'ACTIVE';
+
+-- A captured hold debits the wallet like a withdrawal, so clients that may
+-- withdraw may also place holds.
+UPDATE clients SET scopes = array_append(scopes, 'wallet:hold')
+WHERE 'wallet:withdraw' = ANY(scopes);
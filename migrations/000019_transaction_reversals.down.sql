@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_transactions_reversal_of;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversed_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversal_of;
+
+-- Postgres cannot drop a single enum value, so the type is rebuilt without them.
+-- This fails while reversal transactions still exist.
+ALTER TYPE transaction_type RENAME TO transaction_type_old;
+CREATE TYPE transaction_type AS ENUM ('TOP_UP', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD_CAPTURE');
+ALTER TABLE transactions ALTER COLUMN type TYPE transaction_type USING type::text::transaction_type;
+DROP TYPE transaction_type_old;
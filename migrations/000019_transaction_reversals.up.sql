@@ -0,0 +1,8 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'REVERSAL_OUT';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'REVERSAL_IN';
+
+ALTER TABLE transactions ADD COLUMN reversal_of UUID REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN reversed_amount NUMERIC(15, 2) NOT NULL DEFAULT 0
+    CHECK (reversed_amount >= 0 AND reversed_amount <= amount);
+
+CREATE INDEX idx_transactions_reversal_of ON transactions(reversal_of) WHERE reversal_of IS NOT NULL;
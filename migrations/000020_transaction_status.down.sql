@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_pending;
+ALTER TABLE transactions DROP COLUMN IF EXISTS failure_reason;
+ALTER TABLE transactions DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS status;
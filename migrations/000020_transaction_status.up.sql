@@ -0,0 +1,10 @@
+ALTER TABLE transactions ADD COLUMN status TEXT NOT NULL DEFAULT 'COMPLETED'
+    CHECK (status IN ('PENDING', 'COMPLETED', 'FAILED', 'REVERSED'));
+ALTER TABLE transactions ADD COLUMN status_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE transactions ADD COLUMN failure_reason TEXT;
+
+-- Every existing transaction completed when it was created.
+UPDATE transactions SET status_changed_at = created_at;
+UPDATE transactions SET status = 'REVERSED' WHERE reversed_amount = amount;
+
+CREATE INDEX idx_transactions_pending ON transactions(created_at) WHERE status = 'PENDING';
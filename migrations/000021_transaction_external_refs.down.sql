@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_client_external_ref;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS external_ref,
+    DROP COLUMN IF EXISTS client_id;
@@ -0,0 +1,8 @@
+ALTER TABLE transactions
+    ADD COLUMN client_id UUID REFERENCES clients(id),
+    ADD COLUMN external_ref TEXT;
+
+-- A partner's own reference identifies at most one of the transactions it
+-- initiated.
+CREATE UNIQUE INDEX idx_transactions_client_external_ref ON transactions(client_id, external_ref)
+    WHERE external_ref IS NOT NULL;
@@ -7,9 +7,13 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/mabduqayum/ewallet/internal/config"
 	"github.com/mabduqayum/ewallet/internal/models"
 	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,25 +21,42 @@ const (
 	numClients     = 10
 	numWallets     = 30
 	maxTopUps      = 10
-	maxTopUpAmount = 1000.0
+	maxTopUpAmount = 1000
 )
 
-func SeedData(ctx context.Context, pool *pgxpool.Pool) error {
-	clientRepo := repository.NewPostgresClientRepository(pool)
+func SeedData(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) error {
+	limitsPolicy, err := services.NewLimitsPolicy(cfg.Wallet)
+	if err != nil {
+		return err
+	}
+	keyring, err := secrets.NewKeyring(cfg.Secrets.CurrentKeyID, cfg.Secrets.MasterKeys)
+	if err != nil {
+		return err
+	}
+
+	transactor := repository.NewPostgresTransactor(pool)
+	clientService := services.NewClientService(transactor, repository.NewPostgresClientRepository(pool),
+		repository.NewPostgresClientSuspensionRepository(pool), repository.NewPostgresClientCredentialRepository(pool),
+		keyring, cfg.Clients.CredentialGracePeriod)
 	walletRepo := repository.NewPostgresWalletRepository(pool)
 	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	ledgerService := services.NewLedgerService(repository.NewPostgresLedgerRepository(pool))
+	limitsEngine := services.NewLimitsEngine(limitsPolicy, transactionRepo, cfg.Wallet.Location)
+	customerService := services.NewCustomerService(repository.NewPostgresCustomerRepository(pool), cfg.Customers.DefaultCountryCode)
+	walletService := services.NewWalletService(transactor, walletRepo, transactionRepo, ledgerService, limitsEngine, customerService,
+		repository.NewPostgresWalletStatusChangeRepository(pool), repository.NewPostgresHoldRepository(pool))
 
-	clients, err := seedClients(ctx, clientRepo)
+	clients, err := seedClients(ctx, clientService)
 	if err != nil {
 		return err
 	}
 
-	wallets, err := seedWallets(ctx, walletRepo)
+	wallets, err := seedWallets(ctx, walletRepo, clients)
 	if err != nil {
 		return err
 	}
 
-	err = seedTransactions(ctx, transactionRepo, walletRepo, wallets)
+	err = seedTransactions(ctx, walletService, clients, wallets)
 	if err != nil {
 		return err
 	}
@@ -44,12 +65,12 @@ func SeedData(ctx context.Context, pool *pgxpool.Pool) error {
 	return nil
 }
 
-func seedClients(ctx context.Context, repo repository.ClientRepository) ([]*models.Client, error) {
+func seedClients(ctx context.Context, clientService *services.ClientService) ([]*models.Client, error) {
 	clients := make([]*models.Client, 0, numClients)
 
 	for i := 1; i <= numClients; i++ {
-		client := models.NewClient(fmt.Sprintf("Client %d", i))
-		if err := repo.Create(ctx, client); err != nil {
+		client, _, err := clientService.CreateClient(ctx, fmt.Sprintf("Client %d", i), nil)
+		if err != nil {
 			return nil, err
 		}
 		clients = append(clients, client)
@@ -58,7 +79,8 @@ func seedClients(ctx context.Context, repo repository.ClientRepository) ([]*mode
 	return clients, nil
 }
 
-func seedWallets(ctx context.Context, repo repository.WalletRepository) ([]*models.Wallet, error) {
+// seedWallets gives each wallet a random owner among the clients.
+func seedWallets(ctx context.Context, repo repository.WalletRepository, clients []*models.Client) ([]*models.Wallet, error) {
 	wallets := make([]*models.Wallet, 0, numWallets)
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
@@ -68,7 +90,8 @@ func seedWallets(ctx context.Context, repo repository.WalletRepository) ([]*mode
 			walletType = models.WalletTypeUnidentified
 		}
 
-		wallet := models.NewWallet(walletType, "TJS")
+		owner := clients[r.Intn(len(clients))]
+		wallet := models.NewWallet(owner.ID, walletType, "TJS")
 		if err := repo.Create(ctx, *wallet); err != nil {
 			return nil, err
 		}
@@ -78,22 +101,21 @@ func seedWallets(ctx context.Context, repo repository.WalletRepository) ([]*mode
 	return wallets, nil
 }
 
-func seedTransactions(ctx context.Context, transactionRepo repository.TransactionRepository, walletRepo repository.WalletRepository, wallets []*models.Wallet) error {
+func seedTransactions(ctx context.Context, walletService *services.WalletService, clients []*models.Client, wallets []*models.Wallet) error {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
+	owners := make(map[uuid.UUID]*models.Client, len(clients))
+	for _, client := range clients {
+		owners[client.ID] = client
+	}
+
 	for _, wallet := range wallets {
+		owner := owners[wallet.ClientID]
 		numTransactions := r.Intn(maxTopUps) + 1
 
 		for i := 0; i < numTransactions; i++ {
-			amount := r.Float64() * maxTopUpAmount
-			transaction := models.NewTransaction(wallet.ID, models.TransactionTypeTopUp, amount, "Initial top-up")
-
-			if err := transactionRepo.Create(ctx, transaction); err != nil {
-				return err
-			}
-
-			wallet.Balance += amount
-			if err := walletRepo.Update(ctx, wallet, amount); err != nil {
+			amount := models.Money(r.Int63n(int64(models.NewMoney(maxTopUpAmount))) + 1)
+			if _, _, err := walletService.TopUpWallet(ctx, owner, wallet.ID, amount, nil); err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+)
+
+func TestAdminActionsAreAudited(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, _ := newWalletService(pool)
+	auditService := services.NewAuditService(repository.NewPostgresAuditRepository(pool))
+	identificationService := newIdentificationService(pool, walletService, auditService)
+	adminService := services.NewAdminService(repository.NewPostgresTransactor(pool), newClientService(t, pool), walletService, identificationService,
+		services.NewReversalService(repository.NewPostgresTransactor(pool), walletService), auditService)
+
+	client, credential, err := adminService.CreateClient(ctx, "ops", "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if credential.Secret == "" {
+		t.Fatal("expected the secret of the new client")
+	}
+	if _, err := adminService.RenameClient(ctx, "ops", client.ID, "Partner Ltd"); err != nil {
+		t.Fatalf("failed to rename client: %v", err)
+	}
+	if _, err := adminService.SetClientSignatureScheme(ctx, "ops", client.ID, "HMAC-MD5"); !errors.Is(err, services.ErrInvalidSignatureScheme) {
+		t.Fatalf("expected ErrInvalidSignatureScheme, got %v", err)
+	}
+	updated, err := adminService.SetClientSignatureScheme(ctx, "ops", client.ID, models.SignatureSchemeHMACSHA256)
+	if err != nil {
+		t.Fatalf("failed to set signature scheme: %v", err)
+	}
+	if updated.SignatureScheme != models.SignatureSchemeHMACSHA256 {
+		t.Fatalf("expected HMAC-SHA256, got %s", updated.SignatureScheme)
+	}
+	if _, err := adminService.DeactivateClient(ctx, "ops", client.ID, "contract ended", nil); err != nil {
+		t.Fatalf("failed to deactivate client: %v", err)
+	}
+	// A failed action leaves no audit entry.
+	if _, err := adminService.RenameClient(ctx, "ops", client.ID, " "); err == nil {
+		t.Fatal("expected an error for an empty name")
+	}
+
+	entries, err := adminService.GetAuditTrail(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("failed to get audit trail: %v", err)
+	}
+	want := []models.AuditAction{models.AuditClientDeactivated, models.AuditClientSchemeChanged, models.AuditClientRenamed, models.AuditClientCreated}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d audit entries, got %d", len(want), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Action != want[i] || entry.Actor != "ops" {
+			t.Errorf("entry %d: got %s by %s; want %s by ops", i, entry.Action, entry.Actor, want[i])
+		}
+	}
+}
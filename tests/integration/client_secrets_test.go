@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientSecretsEncryptedAtRest(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	clientService := newClientService(t, pool)
+
+	client, credential, err := clientService.CreateClient(ctx, "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var stored string
+	if err := pool.QueryRow(ctx, "SELECT secret FROM client_credentials WHERE id = $1", credential.ID).Scan(&stored); err != nil {
+		t.Fatalf("failed to read secret: %v", err)
+	}
+	if stored == string(credential.Secret) {
+		t.Fatal("secret is stored in plaintext")
+	}
+
+	// Legacy rows hold a plaintext secret without a key ID until re-encrypted.
+	if _, err := pool.Exec(ctx, "UPDATE client_credentials SET secret = 'legacy', secret_key_id = NULL WHERE id = $1", credential.ID); err != nil {
+		t.Fatalf("failed to store legacy secret: %v", err)
+	}
+	reencrypted, err := clientService.ReencryptSecrets(ctx)
+	if err != nil || reencrypted != 1 {
+		t.Fatalf("expected 1 re-encrypted secret, got %d: %v", reencrypted, err)
+	}
+
+	_, clientSecrets, err := clientService.AuthenticateClient(ctx, client.ApiKey)
+	if err != nil {
+		t.Fatalf("failed to authenticate client: %v", err)
+	}
+	if len(clientSecrets) != 1 || clientSecrets[0] != "legacy" {
+		t.Errorf("re-encrypted secret doesn't decrypt to the original")
+	}
+}
+
+func TestPlaintextSecretsAreEncrypted(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	clientService := newClientService(t, pool)
+
+	client, credential, err := clientService.CreateClient(ctx, "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "UPDATE client_credentials SET secret = 'legacy', secret_key_id = NULL WHERE id = $1", credential.ID); err != nil {
+		t.Fatalf("failed to store legacy secret: %v", err)
+	}
+
+	encrypted, err := clientService.EncryptPlaintextSecrets(ctx)
+	if err != nil || encrypted != 1 {
+		t.Fatalf("expected 1 encrypted secret, got %d: %v", encrypted, err)
+	}
+	var stored string
+	var keyID *string
+	if err := pool.QueryRow(ctx, "SELECT secret, secret_key_id FROM client_credentials WHERE id = $1", credential.ID).Scan(&stored, &keyID); err != nil {
+		t.Fatalf("failed to read secret: %v", err)
+	}
+	if stored == "legacy" || keyID == nil {
+		t.Fatal("legacy secret is still stored in plaintext")
+	}
+	if encrypted, err := clientService.EncryptPlaintextSecrets(ctx); err != nil || encrypted != 0 {
+		t.Fatalf("expected nothing left to encrypt, got %d: %v", encrypted, err)
+	}
+
+	_, clientSecrets, err := clientService.AuthenticateClient(ctx, client.ApiKey)
+	if err != nil {
+		t.Fatalf("failed to authenticate client: %v", err)
+	}
+	if len(clientSecrets) != 1 || clientSecrets[0] != "legacy" {
+		t.Errorf("encrypted secret doesn't decrypt to the original")
+	}
+}
+
+func TestClientCredentialRotation(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	clientService := newClientService(t, pool)
+
+	client, old, err := clientService.CreateClient(ctx, "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	issued, err := clientService.IssueCredential(ctx, client.ID, nil, false)
+	if err != nil {
+		t.Fatalf("failed to issue credential: %v", err)
+	}
+
+	// Both secrets work during the grace period, the newest first.
+	_, clientSecrets, err := clientService.AuthenticateClient(ctx, client.ApiKey)
+	if err != nil {
+		t.Fatalf("failed to authenticate client: %v", err)
+	}
+	if len(clientSecrets) != 2 || clientSecrets[0] != issued.Secret || clientSecrets[1] != old.Secret {
+		t.Fatalf("expected the new and the old secret to be valid, got %d secrets", len(clientSecrets))
+	}
+
+	credentials, err := clientService.ListCredentials(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("failed to list credentials: %v", err)
+	}
+	var graceEnd *time.Time
+	for _, c := range credentials {
+		if c.ID == old.ID {
+			graceEnd = c.ExpiresAt
+		}
+	}
+	if graceEnd == nil || graceEnd.Sub(time.Now()) > time.Hour {
+		t.Fatalf("expected the old credential to expire within the grace period, got %v", graceEnd)
+	}
+
+	if err := clientService.RevokeCredential(ctx, client.ID, old.ID); err != nil {
+		t.Fatalf("failed to revoke credential: %v", err)
+	}
+	_, clientSecrets, _ = clientService.AuthenticateClient(ctx, client.ApiKey)
+	if len(clientSecrets) != 1 || clientSecrets[0] != issued.Secret {
+		t.Fatalf("expected only the new secret after revoking the old one")
+	}
+	if err := clientService.RevokeCredential(ctx, client.ID, old.ID); err == nil {
+		t.Error("expected an error revoking a revoked credential")
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+	"github.com/mabduqayum/ewallet/internal/utils/secrets"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newClientService(t *testing.T, pool *pgxpool.Pool) *services.ClientService {
+	t.Helper()
+	keyring, err := secrets.NewKeyring("test", map[string]string{
+		"test": base64.StdEncoding.EncodeToString(make([]byte, secrets.KeySize)),
+	})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	return services.NewClientService(
+		repository.NewPostgresTransactor(pool),
+		repository.NewPostgresClientRepository(pool),
+		repository.NewPostgresClientSuspensionRepository(pool),
+		repository.NewPostgresClientCredentialRepository(pool),
+		keyring,
+		time.Hour,
+	)
+}
+
+func TestClientSuspension(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	clientService := newClientService(t, pool)
+
+	client, _, err := clientService.CreateClient(ctx, "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	reactivateAt := time.Now().Add(time.Hour)
+	if _, err := clientService.SuspendClient(ctx, client.ID, "chargebacks", "ops", &reactivateAt); err != nil {
+		t.Fatalf("failed to suspend client: %v", err)
+	}
+	if _, err := clientService.SuspendClient(ctx, client.ID, "again", "ops", nil); !errors.Is(err, services.ErrClientAlreadySuspended) {
+		t.Fatalf("expected ErrClientAlreadySuspended, got %v", err)
+	}
+
+	stored, err := clientService.GetClientByID(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if stored.Active {
+		t.Fatal("suspended client must be inactive")
+	}
+
+	// Nothing is due yet.
+	if err := clientService.ReactivateDueClients(ctx); err != nil {
+		t.Fatalf("scheduled reactivation failed: %v", err)
+	}
+	if stored, _ = clientService.GetClientByID(ctx, client.ID); stored.Active {
+		t.Fatal("client reactivated before its scheduled time")
+	}
+
+	if err := clientService.ReactivateClient(ctx, client.ID, "ops"); err != nil {
+		t.Fatalf("failed to reactivate client: %v", err)
+	}
+	if stored, _ = clientService.GetClientByID(ctx, client.ID); !stored.Active {
+		t.Fatal("reactivated client must be active")
+	}
+
+	suspensions, err := clientService.GetClientSuspensions(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("failed to get suspensions: %v", err)
+	}
+	if len(suspensions) != 1 || suspensions[0].LiftedAt == nil || *suspensions[0].LiftedBy != "ops" {
+		t.Fatalf("expected one lifted suspension, got %+v", suspensions)
+	}
+}
@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestHoldLifecycle(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	holdService := services.NewHoldService(repository.NewPostgresTransactor(pool), repository.NewPostgresHoldRepository(pool), walletService, time.Hour)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(100), nil); err != nil {
+		t.Fatalf("failed to top up wallet: %v", err)
+	}
+
+	hold, err := holdService.Place(ctx, client, wallet.ID, models.NewMoney(70), "Order 42")
+	if err != nil {
+		t.Fatalf("failed to place hold: %v", err)
+	}
+	assertBalances(t, walletService, client, wallet.ID, models.NewMoney(100), models.NewMoney(30))
+
+	if _, err := holdService.Place(ctx, client, wallet.ID, models.NewMoney(31), ""); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds for a hold above the available balance, got %v", err)
+	}
+	if err := walletService.WithdrawWallet(ctx, client, wallet.ID, models.NewMoney(31)); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds for a withdrawal of held money, got %v", err)
+	}
+	if _, err := holdService.Capture(ctx, other, hold.ID, 0); !errors.Is(err, services.ErrHoldNotFound) {
+		t.Fatalf("expected ErrHoldNotFound for another client's hold, got %v", err)
+	}
+
+	captured, err := holdService.Capture(ctx, client, hold.ID, models.NewMoney(50))
+	if err != nil {
+		t.Fatalf("failed to capture hold: %v", err)
+	}
+	if captured.Status != models.HoldStatusCaptured || captured.CaptureTransactionID == nil {
+		t.Fatalf("unexpected hold after capture: %+v", captured)
+	}
+	assertBalances(t, walletService, client, wallet.ID, models.NewMoney(50), models.NewMoney(50))
+
+	if _, err := holdService.Release(ctx, client, hold.ID); !errors.Is(err, models.ErrHoldNotActive) {
+		t.Fatalf("expected ErrHoldNotActive releasing a captured hold, got %v", err)
+	}
+
+	released, err := holdService.Place(ctx, client, wallet.ID, models.NewMoney(20), "")
+	if err != nil {
+		t.Fatalf("failed to place hold: %v", err)
+	}
+	if _, err := holdService.Release(ctx, client, released.ID); err != nil {
+		t.Fatalf("failed to release hold: %v", err)
+	}
+	assertBalances(t, walletService, client, wallet.ID, models.NewMoney(50), models.NewMoney(50))
+}
+
+func TestExpiredHoldsStopReservingMoney(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	holdRepo := repository.NewPostgresHoldRepository(pool)
+	holdService := services.NewHoldService(repository.NewPostgresTransactor(pool), holdRepo, walletService, time.Millisecond)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(10), nil); err != nil {
+		t.Fatalf("failed to top up wallet: %v", err)
+	}
+	hold, err := holdService.Place(ctx, client, wallet.ID, models.NewMoney(10), "")
+	if err != nil {
+		t.Fatalf("failed to place hold: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	assertBalances(t, walletService, client, wallet.ID, models.NewMoney(10), models.NewMoney(10))
+	if err := holdService.ExpireHolds(ctx); err != nil {
+		t.Fatalf("failed to expire holds: %v", err)
+	}
+	expired, err := holdService.Get(ctx, client, hold.ID)
+	if err != nil {
+		t.Fatalf("failed to get hold: %v", err)
+	}
+	if expired.Status != models.HoldStatusExpired {
+		t.Fatalf("expected EXPIRED hold, got %s", expired.Status)
+	}
+}
+
+func assertBalances(t *testing.T, walletService *services.WalletService, client *models.Client, walletID uuid.UUID, balance, available models.Money) {
+	t.Helper()
+	wallet, err := walletService.GetWallet(context.Background(), client, walletID)
+	if err != nil {
+		t.Fatalf("failed to get wallet: %v", err)
+	}
+	got, err := walletService.AvailableBalance(context.Background(), wallet)
+	if err != nil {
+		t.Fatalf("failed to get available balance: %v", err)
+	}
+	if wallet.Balance != balance || got != available {
+		t.Fatalf("expected balance %s and available %s; got %s and %s", balance, available, wallet.Balance, got)
+	}
+}
@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newIdentificationService(pool *pgxpool.Pool, walletService *services.WalletService, auditService *services.AuditService) *services.IdentificationService {
+	return services.NewIdentificationService(repository.NewPostgresTransactor(pool), repository.NewPostgresIdentificationRepository(pool),
+		repository.NewPostgresWalletRepository(pool), repository.NewPostgresCustomerRepository(pool), walletService, auditService, time.UTC)
+}
+
+func TestIdentificationLifecycle(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	auditService := services.NewAuditService(repository.NewPostgresAuditRepository(pool))
+	identificationService := newIdentificationService(pool, walletService, auditService)
+	client := newTestClient(t, pool)
+
+	wallet, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "TJS", nil,
+		&models.CustomerDetails{Phone: "+992901234567"})
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	expiresAt := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	identification, err := identificationService.Submit(ctx, client, wallet.ID, models.DocumentTypePassport, "A1234567", expiresAt)
+	if err != nil {
+		t.Fatalf("failed to submit identification: %v", err)
+	}
+	if _, err := identificationService.Submit(ctx, client, wallet.ID, models.DocumentTypePassport, "A1234567", expiresAt); !errors.Is(err, services.ErrIdentificationPending) {
+		t.Fatalf("expected ErrIdentificationPending, got %v", err)
+	}
+
+	if _, err := identificationService.Approve(ctx, "ops", identification.ID); err != nil {
+		t.Fatalf("failed to approve identification: %v", err)
+	}
+	stored, _ := walletRepo.GetByID(ctx, wallet.ID)
+	if stored.Type != models.WalletTypeIdentified {
+		t.Fatalf("expected an IDENTIFIED wallet after approval, got %s", stored.Type)
+	}
+
+	// Let the document expire.
+	if _, err := pool.Exec(ctx, "UPDATE identifications SET document_expires_at = CURRENT_DATE - 1 WHERE id = $1", identification.ID); err != nil {
+		t.Fatalf("failed to backdate document: %v", err)
+	}
+	if err := identificationService.ExpireDocuments(ctx); err != nil {
+		t.Fatalf("expiry job failed: %v", err)
+	}
+	stored, _ = walletRepo.GetByID(ctx, wallet.ID)
+	if stored.Type != models.WalletTypeUnidentified {
+		t.Fatalf("expected an UNIDENTIFIED wallet after expiry, got %s", stored.Type)
+	}
+
+	trail, err := auditService.GetTrail(ctx, models.AuditTargetWallet, wallet.ID)
+	if err != nil {
+		t.Fatalf("failed to get audit trail: %v", err)
+	}
+	want := []models.AuditAction{models.AuditIdentificationExpired, models.AuditIdentificationApproved, models.AuditIdentificationSubmitted}
+	if len(trail) != len(want) {
+		t.Fatalf("expected %d audit entries, got %d", len(want), len(trail))
+	}
+	for i, action := range want {
+		if trail[i].Action != action {
+			t.Errorf("audit entry %d: got %s; want %s", i, trail[i].Action, action)
+		}
+	}
+}
+
+func TestCustomerStaysIdentifiedWhileAnyWalletIsApproved(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, _ := newWalletService(pool)
+	identificationService := newIdentificationService(pool, walletService, services.NewAuditService(repository.NewPostgresAuditRepository(pool)))
+	client := newTestClient(t, pool)
+
+	holder := &models.CustomerDetails{Phone: "+992937778899"}
+	expiresAt := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	var identifications []*models.Identification
+	var customerID any
+	for i := 0; i < 2; i++ {
+		wallet, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "TJS", nil, holder)
+		if err != nil {
+			t.Fatalf("failed to create wallet: %v", err)
+		}
+		customerID = *wallet.CustomerID
+		identification, err := identificationService.Submit(ctx, client, wallet.ID, models.DocumentTypePassport, "A1234567", expiresAt)
+		if err != nil {
+			t.Fatalf("failed to submit identification: %v", err)
+		}
+		if _, err := identificationService.Approve(ctx, "ops", identification.ID); err != nil {
+			t.Fatalf("failed to approve identification: %v", err)
+		}
+		identifications = append(identifications, identification)
+	}
+
+	customerStatus := func() models.IdentificationStatus {
+		t.Helper()
+		var status models.IdentificationStatus
+		if err := pool.QueryRow(ctx, "SELECT identification_status FROM customers WHERE id = $1", customerID).Scan(&status); err != nil {
+			t.Fatalf("failed to read customer: %v", err)
+		}
+		return status
+	}
+
+	// Only the first wallet's document expires.
+	if _, err := pool.Exec(ctx, "UPDATE identifications SET document_expires_at = CURRENT_DATE - 1 WHERE id = $1", identifications[0].ID); err != nil {
+		t.Fatalf("failed to backdate document: %v", err)
+	}
+	if err := identificationService.ExpireDocuments(ctx); err != nil {
+		t.Fatalf("expiry job failed: %v", err)
+	}
+	if status := customerStatus(); status != models.IdentificationStatusIdentified {
+		t.Fatalf("expected the customer to stay IDENTIFIED, got %s", status)
+	}
+
+	if _, err := pool.Exec(ctx, "UPDATE identifications SET document_expires_at = CURRENT_DATE - 1 WHERE id = $1", identifications[1].ID); err != nil {
+		t.Fatalf("failed to backdate document: %v", err)
+	}
+	if err := identificationService.ExpireDocuments(ctx); err != nil {
+		t.Fatalf("expiry job failed: %v", err)
+	}
+	if status := customerStatus(); status != models.IdentificationStatusUnidentified {
+		t.Fatalf("expected the customer to be UNIDENTIFIED once no wallet is approved, got %s", status)
+	}
+}
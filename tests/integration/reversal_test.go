@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestReverseTopUp(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	reversalService := services.NewReversalService(repository.NewPostgresTransactor(pool), walletService)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(100), nil); err != nil {
+		t.Fatalf("failed to top up wallet: %v", err)
+	}
+	topUp := latestTransaction(t, transactionRepo, wallet.ID)
+
+	if _, err := reversalService.Reverse(ctx, topUp.ID, models.NewMoney(30), ""); !errors.Is(err, services.ErrReversalReasonRequired) {
+		t.Fatalf("expected ErrReversalReasonRequired, got %v", err)
+	}
+	reversals, err := reversalService.Reverse(ctx, topUp.ID, models.NewMoney(30), "Duplicate top-up")
+	if err != nil {
+		t.Fatalf("failed to reverse part of the top-up: %v", err)
+	}
+	if len(reversals) != 1 || reversals[0].Type != models.TransactionTypeReversalOut || *reversals[0].ReversalOf != topUp.ID {
+		t.Fatalf("unexpected reversals: %+v", reversals)
+	}
+	if _, err := reversalService.Reverse(ctx, reversals[0].ID, 0, "Undo"); !errors.Is(err, models.ErrTransactionNotReversible) {
+		t.Fatalf("expected ErrTransactionNotReversible, got %v", err)
+	}
+
+	if err := walletService.WithdrawWallet(ctx, client, wallet.ID, models.NewMoney(50)); err != nil {
+		t.Fatalf("failed to withdraw: %v", err)
+	}
+	if _, err := reversalService.Reverse(ctx, topUp.ID, 0, "Duplicate top-up"); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds below the balance floor, got %v", err)
+	}
+	if _, err := reversalService.Reverse(ctx, topUp.ID, models.NewMoney(20), "Duplicate top-up"); err != nil {
+		t.Fatalf("failed to reverse the rest of the top-up: %v", err)
+	}
+	if _, err := reversalService.Reverse(ctx, topUp.ID, 0, "Duplicate top-up"); !errors.Is(err, models.ErrTransactionAlreadyReversed) {
+		t.Fatalf("expected ErrTransactionAlreadyReversed, got %v", err)
+	}
+
+	reversed, err := transactionRepo.GetByID(ctx, topUp.ID)
+	if err != nil {
+		t.Fatalf("failed to get top-up: %v", err)
+	}
+	if reversed.ReversedAmount != topUp.Amount {
+		t.Fatalf("expected the whole top-up to be reversed, got %s", reversed.ReversedAmount)
+	}
+	if balance, err := walletService.GetBalance(ctx, client, wallet.ID); err != nil || balance != 0 {
+		t.Fatalf("expected an empty wallet, got %s, %v", balance, err)
+	}
+}
+
+func TestReverseTransferReversesBothLegs(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	reversalService := services.NewReversalService(repository.NewPostgresTransactor(pool), walletService)
+	client := newTestClient(t, pool)
+
+	source := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	destination := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	for _, wallet := range []*models.Wallet{source, destination} {
+		if err := walletRepo.Create(ctx, *wallet); err != nil {
+			t.Fatalf("failed to create wallet: %v", err)
+		}
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, source.ID, models.NewMoney(10), nil); err != nil {
+		t.Fatalf("failed to top up wallet: %v", err)
+	}
+	if _, err := walletService.Transfer(ctx, client, source.ID, destination.ID, models.NewMoney(10), "Rent"); err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+
+	incoming := latestTransaction(t, transactionRepo, destination.ID)
+	reversals, err := reversalService.Reverse(ctx, incoming.ID, models.NewMoney(4), "Wrong amount")
+	if err != nil {
+		t.Fatalf("failed to reverse transfer: %v", err)
+	}
+	if len(reversals) != 2 {
+		t.Fatalf("expected a reversal per leg, got %d", len(reversals))
+	}
+
+	for walletID, want := range map[uuid.UUID]models.Money{source.ID: models.NewMoney(4), destination.ID: models.NewMoney(6)} {
+		if balance, err := walletService.GetBalance(ctx, client, walletID); err != nil || balance != want {
+			t.Fatalf("expected balance %s, got %s, %v", want, balance, err)
+		}
+	}
+}
+
+func latestTransaction(t *testing.T, repo repository.TransactionRepository, walletID uuid.UUID) *models.Transaction {
+	t.Helper()
+	transactions, err := repo.GetByWalletID(context.Background(), walletID, 1, 0)
+	if err != nil || len(transactions) == 0 {
+		t.Fatalf("failed to get the latest transaction: %v", err)
+	}
+	return transactions[0]
+}
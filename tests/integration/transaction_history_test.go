@@ -0,0 +1,213 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionHistoryPagesThroughIdenticalTimestamps(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	transactionService := services.NewTransactionService(transactionRepo, walletService, time.UTC)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	// Five top-ups share one timestamp, so only the ID orders them.
+	base := time.Date(2024, 8, 31, 12, 0, 0, 0, time.UTC)
+	create := func(transactionType models.TransactionType, amount int64, createdAt time.Time) *models.Transaction {
+		t.Helper()
+		transaction := models.NewTransaction(wallet.ID, transactionType, models.NewMoney(amount), "History")
+		transaction.CreatedAt, transaction.StatusChangedAt, transaction.UpdatedAt = createdAt, createdAt, createdAt
+		if err := transactionRepo.Create(ctx, transaction); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+		return transaction
+	}
+	for i := int64(1); i <= 5; i++ {
+		create(models.TransactionTypeTopUp, i, base)
+	}
+	create(models.TransactionTypeWithdraw, 3, base.Add(-time.Hour))
+	create(models.TransactionTypeWithdraw, 7, base.Add(time.Hour))
+
+	var all []*models.Transaction
+	filter := models.TransactionFilter{WalletID: wallet.ID, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 7 {
+			t.Fatal("paging does not terminate")
+		}
+		page, err := transactionService.ListTransactions(ctx, client, filter)
+		if err != nil {
+			t.Fatalf("failed to list transactions: %v", err)
+		}
+		all = append(all, page.Transactions...)
+		if page.NextCursor == "" {
+			break
+		}
+		if filter.After, err = models.DecodeTransactionCursor(page.NextCursor); err != nil {
+			t.Fatalf("failed to decode cursor: %v", err)
+		}
+	}
+
+	if len(all) != 7 {
+		t.Fatalf("expected 7 transactions across all pages, got %d", len(all))
+	}
+	seen := map[uuid.UUID]bool{}
+	for i, transaction := range all {
+		if seen[transaction.ID] {
+			t.Fatalf("transaction %s appears on two pages", transaction.ID)
+		}
+		seen[transaction.ID] = true
+		if i == 0 {
+			continue
+		}
+		prev := all[i-1]
+		if transaction.CreatedAt.After(prev.CreatedAt) ||
+			transaction.CreatedAt.Equal(prev.CreatedAt) && transaction.ID.String() > prev.ID.String() {
+			t.Errorf("transaction %d is out of order", i)
+		}
+	}
+
+	minAmount, maxAmount := models.NewMoney(3), models.NewMoney(5)
+	from, to := base.Add(-30*time.Minute), base.Add(30*time.Minute)
+	filters := []struct {
+		name   string
+		filter models.TransactionFilter
+		want   int
+	}{
+		{"type", models.TransactionFilter{Types: []models.TransactionType{models.TransactionTypeWithdraw}}, 2},
+		{"amount range", models.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}, 4},
+		{"time range", models.TransactionFilter{From: &from, To: &to}, 5},
+		{"to is exclusive", models.TransactionFilter{From: &from, To: &base}, 0},
+		{"combined", models.TransactionFilter{Types: []models.TransactionType{models.TransactionTypeTopUp}, MinAmount: &minAmount, From: &from}, 3},
+	}
+	for _, tt := range filters {
+		tt.filter.WalletID = wallet.ID
+		page, err := transactionService.ListTransactions(ctx, client, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: failed to list transactions: %v", tt.name, err)
+		}
+		if len(page.Transactions) != tt.want {
+			t.Errorf("%s: got %d transactions; want %d", tt.name, len(page.Transactions), tt.want)
+		}
+	}
+}
+
+func TestTransactionStatsCountOnlySettledTransactionsInRange(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	transactionService := services.NewTransactionService(transactionRepo, walletService, time.UTC)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	for _, tt := range []struct {
+		amount    int64
+		status    models.TransactionStatus
+		createdAt time.Time
+	}{
+		{10, models.TransactionStatusCompleted, from},
+		{30, models.TransactionStatusCompleted, to.Add(-time.Second)},
+		{50, models.TransactionStatusPending, from.Add(time.Hour)},
+		{70, models.TransactionStatusFailed, from.Add(time.Hour)},
+		{90, models.TransactionStatusCompleted, to},
+	} {
+		transaction := models.NewTransaction(wallet.ID, models.TransactionTypeTopUp, models.NewMoney(tt.amount), "Stats")
+		transaction.Status = tt.status
+		transaction.CreatedAt, transaction.StatusChangedAt, transaction.UpdatedAt = tt.createdAt, tt.createdAt, tt.createdAt
+		if err := transactionRepo.Create(ctx, transaction); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+
+	report, err := transactionService.GetStats(ctx, client, wallet.ID, models.StatsQuery{From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	topUps := report.Stats[models.TransactionTypeTopUp]
+	if topUps == nil || topUps.Count != 2 || topUps.Sum != models.NewMoney(40) || topUps.Min != models.NewMoney(10) ||
+		topUps.Max != models.NewMoney(30) || topUps.Average != models.NewMoney(20) {
+		t.Fatalf("unexpected top-up stats: %+v", topUps)
+	}
+	if len(report.Stats) != 1 {
+		t.Errorf("expected stats for top-ups only, got %d types", len(report.Stats))
+	}
+}
+
+func TestSettledSumCountsMoneyWhenItSettledLessReversals(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	_, walletRepo := newWalletService(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeUnidentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	today := time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	for _, tt := range []struct {
+		transactionType models.TransactionType
+		amount          int64
+		reversed        int64
+		status          models.TransactionStatus
+		createdAt       time.Time
+		settledAt       time.Time
+	}{
+		// Created yesterday as pending, completed today.
+		{models.TransactionTypeTopUp, 100, 0, models.TransactionStatusCompleted, yesterday.Add(23 * time.Hour), today.Add(time.Hour)},
+		{models.TransactionTypeTopUp, 70, 0, models.TransactionStatusCompleted, yesterday.Add(time.Hour), yesterday.Add(time.Hour)},
+		{models.TransactionTypeTopUp, 50, 0, models.TransactionStatusPending, today.Add(time.Hour), today.Add(time.Hour)},
+		{models.TransactionTypeTopUp, 40, 15, models.TransactionStatusCompleted, today.Add(time.Hour), today.Add(time.Hour)},
+		{models.TransactionTypeTopUp, 30, 30, models.TransactionStatusReversed, today.Add(time.Hour), today.Add(2 * time.Hour)},
+		{models.TransactionTypeTransferIn, 20, 0, models.TransactionStatusCompleted, today.Add(time.Hour), today.Add(time.Hour)},
+		{models.TransactionTypeWithdraw, 10, 0, models.TransactionStatusCompleted, today.Add(time.Hour), today.Add(time.Hour)},
+	} {
+		transaction := models.NewTransaction(wallet.ID, tt.transactionType, models.NewMoney(tt.amount), "Turnover")
+		transaction.ReversedAmount = models.NewMoney(tt.reversed)
+		transaction.Status = tt.status
+		transaction.CreatedAt, transaction.UpdatedAt, transaction.StatusChangedAt = tt.createdAt, tt.settledAt, tt.settledAt
+		if err := transactionRepo.Create(ctx, transaction); err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+	}
+
+	incoming := []models.TransactionType{models.TransactionTypeTopUp, models.TransactionTypeTransferIn}
+	for _, tt := range []struct {
+		name string
+		from time.Time
+		want models.Money
+	}{
+		{"today", today, models.NewMoney(100 + 25 + 20)},
+		{"yesterday", yesterday, models.NewMoney(70)},
+	} {
+		got, err := transactionRepo.GetSettledSum(ctx, wallet.ID, incoming, tt.from, tt.from.AddDate(0, 0, 1))
+		if err != nil {
+			t.Fatalf("%s: failed to sum: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s; want %s", tt.name, got, tt.want)
+		}
+	}
+}
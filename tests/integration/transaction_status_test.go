@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+)
+
+func TestPendingTransactionsChangeBalanceOnlyOnCompletion(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	topUp, err := walletService.TopUpWalletPending(ctx, client, wallet.ID, models.NewMoney(10), nil)
+	if err != nil {
+		t.Fatalf("failed to create pending top-up: %v", err)
+	}
+	if balance, err := walletService.GetBalance(ctx, client, wallet.ID); err != nil || balance != 0 {
+		t.Fatalf("pending top-up must not change the balance; got %s, %v", balance, err)
+	}
+
+	completed, err := walletService.CompleteTransaction(ctx, topUp.ID)
+	if err != nil {
+		t.Fatalf("failed to complete top-up: %v", err)
+	}
+	if completed.Status != models.TransactionStatusCompleted {
+		t.Fatalf("expected COMPLETED, got %s", completed.Status)
+	}
+	if balance, err := walletService.GetBalance(ctx, client, wallet.ID); err != nil || balance != models.NewMoney(10) {
+		t.Fatalf("expected balance 10 after completion; got %s, %v", balance, err)
+	}
+	if _, err := walletService.CompleteTransaction(ctx, topUp.ID); !errors.Is(err, models.ErrInvalidTransactionTransition) {
+		t.Fatalf("expected ErrInvalidTransactionTransition completing twice, got %v", err)
+	}
+
+	ref := "bank-7"
+	rejected, err := walletService.TopUpWalletPending(ctx, client, wallet.ID, models.NewMoney(4), &ref)
+	if err != nil {
+		t.Fatalf("failed to create pending top-up: %v", err)
+	}
+	if _, err := walletService.TopUpWalletPending(ctx, client, wallet.ID, models.NewMoney(4), &ref); !errors.Is(err, services.ErrDuplicateTransactionRef) {
+		t.Fatalf("expected ErrDuplicateTransactionRef, got %v", err)
+	}
+	if _, err := walletService.FailTransaction(ctx, rejected.ID, ""); !errors.Is(err, services.ErrFailureReasonRequired) {
+		t.Fatalf("expected ErrFailureReasonRequired, got %v", err)
+	}
+	failed, err := walletService.FailTransaction(ctx, rejected.ID, "Rejected by the bank")
+	if err != nil {
+		t.Fatalf("failed to fail top-up: %v", err)
+	}
+	if failed.Status != models.TransactionStatusFailed || *failed.FailureReason != "Rejected by the bank" {
+		t.Fatalf("unexpected failed transaction: %+v", failed)
+	}
+	if balance, err := walletService.GetBalance(ctx, client, wallet.ID); err != nil || balance != models.NewMoney(10) {
+		t.Fatalf("failed top-up must not change the balance; got %s, %v", balance, err)
+	}
+}
+
+func TestPendingTopUpIsRejectedForWalletThatCannotBeCredited(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusBlocked, "fraud check", "admin"); err != nil {
+		t.Fatalf("failed to block wallet: %v", err)
+	}
+
+	if _, err := walletService.TopUpWalletPending(ctx, client, wallet.ID, models.NewMoney(10), nil); !errors.Is(err, models.ErrCreditsNotAllowed) {
+		t.Fatalf("expected ErrCreditsNotAllowed, got %v", err)
+	}
+	if count := countTransactions(t, pool, wallet.ID); count != 0 {
+		t.Fatalf("a rejected top-up must not be recorded, got %d transactions", count)
+	}
+}
+
+func TestTopUpExternalRefIsUniquePerClientAndFindsTheTransaction(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	transactionService := services.NewTransactionService(repository.NewPostgresTransactionRepository(pool), walletService, time.UTC)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	otherWallet := models.NewWallet(other.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *otherWallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	ref := "partner-42"
+	topUp, balance, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(10), &ref)
+	if err != nil {
+		t.Fatalf("failed to top up: %v", err)
+	}
+	if balance != models.NewMoney(10) || topUp.Status != models.TransactionStatusCompleted {
+		t.Fatalf("unexpected top-up result: balance %s, status %s", balance, topUp.Status)
+	}
+
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(5), &ref); !errors.Is(err, services.ErrDuplicateTransactionRef) {
+		t.Fatalf("expected ErrDuplicateTransactionRef, got %v", err)
+	}
+	if got, err := walletService.GetBalance(ctx, client, wallet.ID); err != nil || got != models.NewMoney(10) {
+		t.Fatalf("rejected top-up must not change the balance; got %s, %v", got, err)
+	}
+	// References are unique per client only.
+	if _, _, err := walletService.TopUpWallet(ctx, other, otherWallet.ID, models.NewMoney(5), &ref); err != nil {
+		t.Fatalf("another client failed to reuse the reference: %v", err)
+	}
+
+	found, err := transactionService.GetTransactionByExternalRef(ctx, client, ref)
+	if err != nil {
+		t.Fatalf("failed to look up by reference: %v", err)
+	}
+	if found.ID != topUp.ID {
+		t.Fatalf("expected transaction %s, got %s", topUp.ID, found.ID)
+	}
+	if _, err := transactionService.GetClientTransaction(ctx, client, topUp.ID); err != nil {
+		t.Fatalf("failed to look up by ID: %v", err)
+	}
+	if _, err := transactionService.GetClientTransaction(ctx, other, topUp.ID); !errors.Is(err, services.ErrTransactionNotFound) {
+		t.Fatalf("expected ErrTransactionNotFound for another client's transaction, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+)
+
+func TestCreateWalletRejectsDuplicateExternalRef(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, _ := newWalletService(pool)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	ref := "acc-1"
+	wallet, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "tjs", &ref, nil)
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if wallet.Currency != "TJS" || wallet.ClientID != client.ID {
+		t.Fatalf("unexpected wallet %+v", wallet)
+	}
+
+	if _, err := walletService.CreateWallet(ctx, client, models.WalletTypeIdentified, "TJS", &ref, nil); !errors.Is(err, services.ErrDuplicateExternalRef) {
+		t.Fatalf("expected ErrDuplicateExternalRef, got %v", err)
+	}
+	// References are unique per client only.
+	if _, err := walletService.CreateWallet(ctx, other, models.WalletTypeIdentified, "TJS", &ref, nil); err != nil {
+		t.Fatalf("another client failed to reuse the reference: %v", err)
+	}
+
+	if _, err := walletService.CreateWallet(ctx, client, models.WalletTypeIdentified, "EUR", nil, nil); !errors.Is(err, services.ErrUnsupportedCurrency) {
+		t.Fatalf("expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestWalletInNewlyConfiguredCurrencyCanBeToppedUp(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	client := newTestClient(t, pool)
+
+	cfg := config.WalletConfig{Currencies: []string{"TJS"}, UnidentifiedLimit: 10_000, IdentifiedLimit: 100_000}
+	limitsPolicy, err := services.NewLimitsPolicy(cfg)
+	if err != nil {
+		t.Fatalf("failed to create limits policy: %v", err)
+	}
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	walletService := services.NewWalletService(repository.NewPostgresTransactor(pool), repository.NewPostgresWalletRepository(pool), transactionRepo,
+		services.NewLedgerService(repository.NewPostgresLedgerRepository(pool)), services.NewLimitsEngine(limitsPolicy, transactionRepo, time.UTC),
+		services.NewCustomerService(repository.NewPostgresCustomerRepository(pool), "992"), repository.NewPostgresWalletStatusChangeRepository(pool),
+		repository.NewPostgresHoldRepository(pool))
+
+	// The migrations created no system accounts for KGS.
+	cfg.Currencies = append(cfg.Currencies, "KGS")
+	if err := limitsPolicy.Reload(cfg); err != nil {
+		t.Fatalf("failed to reload limits policy: %v", err)
+	}
+
+	wallet, err := walletService.CreateWallet(ctx, client, models.WalletTypeIdentified, "KGS", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, balance, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(10), nil); err != nil || balance != models.NewMoney(10) {
+		t.Fatalf("failed to top up the new currency's wallet: %s, %v", balance, err)
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+)
+
+func TestResolveWalletByPhone(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, _ := newWalletService(pool)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	wallet, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "TJS", nil,
+		&models.CustomerDetails{Phone: "+992 90 123-45-67"})
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	// The national format resolves to the same customer.
+	resolved, err := walletService.ResolveWalletByPhone(ctx, client, "901234567", "")
+	if err != nil {
+		t.Fatalf("failed to resolve wallet: %v", err)
+	}
+	if resolved.ID != wallet.ID {
+		t.Fatalf("resolved wallet %s; want %s", resolved.ID, wallet.ID)
+	}
+
+	if _, err := walletService.ResolveWalletByPhone(ctx, other, "+992901234567", ""); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("expected ErrWalletNotFound for another client, got %v", err)
+	}
+
+	second, err := walletService.CreateWallet(ctx, client, models.WalletTypeIdentified, "TJS", nil,
+		&models.CustomerDetails{Phone: "00992901234567"})
+	if err != nil {
+		t.Fatalf("failed to create second wallet: %v", err)
+	}
+	if *second.CustomerID != *wallet.CustomerID {
+		t.Fatal("expected both wallets to belong to one customer")
+	}
+	if _, err := walletService.ResolveWalletByPhone(ctx, client, "+992901234567", "TJS"); !errors.Is(err, services.ErrAmbiguousWallet) {
+		t.Fatalf("expected ErrAmbiguousWallet, got %v", err)
+	}
+}
+
+func TestHolderDetailsMustMatchTheCustomerOnRecord(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, _ := newWalletService(pool)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	phone := "+992 93 555-00-11"
+	if _, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "TJS", nil,
+		&models.CustomerDetails{Phone: phone}); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	// Details the customer lacks are added by whichever client knows them.
+	name := "Ali Valiev"
+	wallet, err := walletService.CreateWallet(ctx, other, models.WalletTypeUnidentified, "TJS", nil,
+		&models.CustomerDetails{Phone: phone, Name: &name})
+	if err != nil {
+		t.Fatalf("failed to create wallet with the holder's name: %v", err)
+	}
+	var stored *string
+	if err := pool.QueryRow(ctx, "SELECT name FROM customers WHERE id = $1", wallet.CustomerID).Scan(&stored); err != nil {
+		t.Fatalf("failed to read customer: %v", err)
+	}
+	if stored == nil || *stored != name {
+		t.Fatalf("expected the customer's name to be filled in, got %v", stored)
+	}
+
+	otherName := "Vali Aliev"
+	if _, err := walletService.CreateWallet(ctx, client, models.WalletTypeUnidentified, "TJS", nil,
+		&models.CustomerDetails{Phone: phone, Name: &otherName}); !errors.Is(err, services.ErrCustomerDetailsMismatch) {
+		t.Fatalf("expected ErrCustomerDetailsMismatch, got %v", err)
+	}
+}
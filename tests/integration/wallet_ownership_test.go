@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestForeignWalletsAreHidden(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	owner := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	wallet := models.NewWallet(owner.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	if _, err := walletService.GetWallet(ctx, other, wallet.ID); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("foreign wallet must not exist for the client, got %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, other, wallet.ID, models.NewMoney(10), nil); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("expected ErrWalletNotFound for a foreign top-up, got %v", err)
+	}
+
+	if err := walletService.GrantAccess(ctx, wallet.ID, other.ID); err != nil {
+		t.Fatalf("failed to grant access: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, other, wallet.ID, models.NewMoney(10), nil); err != nil {
+		t.Fatalf("granted client failed to top up: %v", err)
+	}
+	if err := walletService.GrantAccess(ctx, wallet.ID, owner.ID); !errors.Is(err, services.ErrWalletOwnedByClient) {
+		t.Fatalf("expected ErrWalletOwnedByClient, got %v", err)
+	}
+
+	if err := walletService.RevokeAccess(ctx, wallet.ID, other.ID); err != nil {
+		t.Fatalf("failed to revoke access: %v", err)
+	}
+	if _, err := walletService.GetBalance(ctx, other, wallet.ID); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("expected ErrWalletNotFound after revocation, got %v", err)
+	}
+	if balance, err := walletService.GetBalance(ctx, owner, wallet.ID); err != nil || balance != models.NewMoney(10) {
+		t.Fatalf("expected the owner to see 10.00; got %s, %v", balance, err)
+	}
+}
+
+func TestWalletsWithoutOwnerAreHiddenUntilAssigned(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+	other := newTestClient(t, pool)
+
+	// Wallets that predate ownership have no owner.
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "UPDATE wallets SET client_id = NULL WHERE id = $1", wallet.ID); err != nil {
+		t.Fatalf("failed to clear the owner: %v", err)
+	}
+
+	if _, err := walletService.GetWallet(ctx, client, wallet.ID); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("a wallet without owner must be hidden, got %v", err)
+	}
+
+	if err := walletService.AssignOwner(ctx, wallet.ID, client.ID); err != nil {
+		t.Fatalf("failed to assign owner: %v", err)
+	}
+	if _, err := walletService.GetWallet(ctx, client, wallet.ID); err != nil {
+		t.Fatalf("owner failed to get the wallet: %v", err)
+	}
+	if err := walletService.AssignOwner(ctx, wallet.ID, other.ID); !errors.Is(err, services.ErrWalletAlreadyOwned) {
+		t.Fatalf("expected ErrWalletAlreadyOwned, got %v", err)
+	}
+	if _, err := walletService.GetWallet(ctx, other, wallet.ID); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("expected ErrWalletNotFound for another client, got %v", err)
+	}
+}
+
+func TestAssignWalletOwnersIsAllOrNothing(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	adminService := services.NewAdminService(repository.NewPostgresTransactor(pool), newClientService(t, pool), walletService, nil, nil,
+		services.NewAuditService(repository.NewPostgresAuditRepository(pool)))
+	client := newTestClient(t, pool)
+
+	var walletIDs []uuid.UUID
+	for i := 0; i < 3; i++ {
+		wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+		if err := walletRepo.Create(ctx, *wallet); err != nil {
+			t.Fatalf("failed to create wallet: %v", err)
+		}
+		walletIDs = append(walletIDs, wallet.ID)
+	}
+	// The first two predate ownership; the last already has its owner.
+	for _, walletID := range walletIDs[:2] {
+		if _, err := pool.Exec(ctx, "UPDATE wallets SET client_id = NULL WHERE id = $1", walletID); err != nil {
+			t.Fatalf("failed to clear the owner: %v", err)
+		}
+	}
+
+	unowned, err := walletService.GetUnownedWalletIDs(ctx)
+	if err != nil {
+		t.Fatalf("failed to list unowned wallets: %v", err)
+	}
+	for _, walletID := range walletIDs[:2] {
+		if !slices.Contains(unowned, walletID) {
+			t.Errorf("expected wallet %s to be listed as unowned", walletID)
+		}
+	}
+
+	if err := adminService.AssignWalletOwners(ctx, "ops", client.ID, walletIDs); !errors.Is(err, services.ErrWalletAlreadyOwned) {
+		t.Fatalf("expected ErrWalletAlreadyOwned, got %v", err)
+	}
+	if _, err := walletService.GetWallet(ctx, client, walletIDs[0]); !errors.Is(err, services.ErrWalletNotFound) {
+		t.Fatalf("a failed bulk assignment must assign nothing, got %v", err)
+	}
+
+	if err := adminService.AssignWalletOwners(ctx, "ops", client.ID, walletIDs[:2]); err != nil {
+		t.Fatalf("failed to assign owners: %v", err)
+	}
+	for _, walletID := range walletIDs[:2] {
+		if _, err := walletService.GetWallet(ctx, client, walletID); err != nil {
+			t.Errorf("owner failed to get wallet %s: %v", walletID, err)
+		}
+	}
+}
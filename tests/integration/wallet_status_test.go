@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/services"
+)
+
+func TestWalletStatusRestrictsMoneyMovements(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(10), nil); err != nil {
+		t.Fatalf("failed to top up wallet: %v", err)
+	}
+
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusFrozenCredits, "", "admin"); !errors.Is(err, services.ErrStatusReasonRequired) {
+		t.Fatalf("expected ErrStatusReasonRequired, got %v", err)
+	}
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusFrozenCredits, "court order", "admin"); err != nil {
+		t.Fatalf("failed to freeze credits: %v", err)
+	}
+	if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(5), nil); !errors.Is(err, models.ErrCreditsNotAllowed) {
+		t.Fatalf("expected ErrCreditsNotAllowed, got %v", err)
+	}
+	if err := walletService.WithdrawWallet(ctx, client, wallet.ID, models.NewMoney(4)); err != nil {
+		t.Fatalf("debits must still be allowed: %v", err)
+	}
+
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusClosed, "customer request", "admin"); !errors.Is(err, services.ErrWalletNotEmpty) {
+		t.Fatalf("expected ErrWalletNotEmpty, got %v", err)
+	}
+	if err := walletService.WithdrawWallet(ctx, client, wallet.ID, models.NewMoney(6)); err != nil {
+		t.Fatalf("failed to empty wallet: %v", err)
+	}
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusClosed, "customer request", "admin"); err != nil {
+		t.Fatalf("failed to close wallet: %v", err)
+	}
+	if _, err := walletService.ChangeStatus(ctx, wallet.ID, models.WalletStatusActive, "reopen", "admin"); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	history, err := walletService.GetStatusHistory(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("failed to get status history: %v", err)
+	}
+	if len(history) != 2 || history[0].ToStatus != models.WalletStatusClosed || history[1].FromStatus != models.WalletStatusActive {
+		t.Fatalf("unexpected status history: %+v", history)
+	}
+}
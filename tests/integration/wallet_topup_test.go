@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mabduqayum/ewallet/internal/config"
+	"github.com/mabduqayum/ewallet/internal/models"
+	"github.com/mabduqayum/ewallet/internal/repository"
+	"github.com/mabduqayum/ewallet/internal/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newWalletService(pool *pgxpool.Pool) (*services.WalletService, repository.WalletRepository) {
+	walletRepo := repository.NewPostgresWalletRepository(pool)
+	transactionRepo := repository.NewPostgresTransactionRepository(pool)
+	ledgerService := services.NewLedgerService(repository.NewPostgresLedgerRepository(pool))
+	limitsPolicy, _ := services.NewLimitsPolicy(config.WalletConfig{Currencies: []string{"TJS"}, UnidentifiedLimit: 10_000, IdentifiedLimit: 100_000})
+	limitsEngine := services.NewLimitsEngine(limitsPolicy, transactionRepo, time.UTC)
+	customerService := services.NewCustomerService(repository.NewPostgresCustomerRepository(pool), "992")
+	statusRepo := repository.NewPostgresWalletStatusChangeRepository(pool)
+	return services.NewWalletService(repository.NewPostgresTransactor(pool), walletRepo, transactionRepo, ledgerService, limitsEngine, customerService, statusRepo,
+		repository.NewPostgresHoldRepository(pool)), walletRepo
+}
+
+// newTestClient stores a client to own the wallets a test creates.
+func newTestClient(t *testing.T, pool *pgxpool.Pool) *models.Client {
+	t.Helper()
+	client, _, err := newClientService(t, pool).CreateClient(context.Background(), "partner", nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func countTransactions(t *testing.T, pool *pgxpool.Pool, walletID any) int {
+	t.Helper()
+	var count int
+	if err := pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM transactions WHERE wallet_id = $1", walletID).Scan(&count); err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+	return count
+}
+
+func TestConcurrentTopUps(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	const topUps = 300
+	amount, _ := models.ParseMoney("0.10")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, topUps)
+	for range topUps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, amount, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("top-up failed: %v", err)
+		}
+	}
+
+	balance, err := walletService.GetBalance(ctx, client, wallet.ID)
+	if err != nil {
+		t.Fatalf("failed to get balance: %v", err)
+	}
+	if balance.String() != "30.00" {
+		t.Errorf("expected balance 30.00; got %s", balance)
+	}
+	if count := countTransactions(t, pool, wallet.ID); count != topUps {
+		t.Errorf("expected %d transactions; got %d", topUps, count)
+	}
+
+	ledgerService := services.NewLedgerService(repository.NewPostgresLedgerRepository(pool))
+	mismatches, err := ledgerService.VerifyWalletBalances(ctx)
+	if err != nil {
+		t.Fatalf("ledger check failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("expected the ledger to match wallet balances; got %d mismatches", len(mismatches))
+	}
+}
+
+func TestConcurrentTopUpsRespectMaxBalance(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	wallet := models.NewWallet(client.ID, models.WalletTypeUnidentified, "TJS")
+	if err := walletRepo.Create(ctx, *wallet); err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+
+	// 10,000 somoni limit / 50 somoni per top-up: exactly 200 must succeed.
+	const topUps = 300
+	amount := models.NewMoney(50)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for range topUps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, amount, nil); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 200 {
+		t.Errorf("expected 200 successful top-ups; got %d", succeeded)
+	}
+
+	balance, err := walletService.GetBalance(ctx, client, wallet.ID)
+	if err != nil {
+		t.Fatalf("failed to get balance: %v", err)
+	}
+	if balance != models.NewMoney(10_000) {
+		t.Errorf("expected balance 10000.00; got %s", balance)
+	}
+	if count := countTransactions(t, pool, wallet.ID); count != 200 {
+		t.Errorf("expected 200 transactions; got %d", count)
+	}
+}
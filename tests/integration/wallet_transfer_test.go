@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mabduqayum/ewallet/internal/models"
+)
+
+func TestConcurrentOppositeTransfers(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	walletService, walletRepo := newWalletService(pool)
+	client := newTestClient(t, pool)
+
+	a := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	b := models.NewWallet(client.ID, models.WalletTypeIdentified, "TJS")
+	for _, wallet := range []*models.Wallet{a, b} {
+		if err := walletRepo.Create(ctx, *wallet); err != nil {
+			t.Fatalf("failed to create wallet: %v", err)
+		}
+		if _, _, err := walletService.TopUpWallet(ctx, client, wallet.ID, models.NewMoney(1_000), nil); err != nil {
+			t.Fatalf("failed to top up wallet: %v", err)
+		}
+	}
+
+	const transfers = 100
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*transfers)
+	for range transfers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := walletService.Transfer(ctx, client, a.ID, b.ID, models.NewMoney(3), "a to b")
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := walletService.Transfer(ctx, client, b.ID, a.ID, models.NewMoney(1), "b to a")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("transfer failed: %v", err)
+		}
+	}
+
+	balanceA, _ := walletService.GetBalance(ctx, client, a.ID)
+	balanceB, _ := walletService.GetBalance(ctx, client, b.ID)
+	if balanceA != models.NewMoney(800) || balanceB != models.NewMoney(1_200) {
+		t.Errorf("expected balances 800.00 and 1200.00; got %s and %s", balanceA, balanceB)
+	}
+	if count := countTransactions(t, pool, a.ID); count != 1+2*transfers {
+		t.Errorf("expected %d transactions for wallet A; got %d", 1+2*transfers, count)
+	}
+}